@@ -0,0 +1,73 @@
+// Copyright 2017 AMIS Technologies
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package client
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+)
+
+// MustParseABI parses a JSON ABI and panics if it is invalid. It is meant for
+// ABIs embedded as constants.
+func MustParseABI(abiJSON string) abi.ABI {
+	parsed, err := abi.JSON(strings.NewReader(abiJSON))
+	if err != nil {
+		panic(err)
+	}
+	return parsed
+}
+
+// packArguments encodes values according to args, without a method selector.
+func packArguments(args []abi.Argument, values ...interface{}) ([]byte, error) {
+	// The constructor of an ABI packs its inputs without a selector.
+	return abi.ABI{Constructor: abi.Method{Inputs: args}}.Pack("", values...)
+}
+
+// unpackArguments decodes data according to args into a slice of Go values.
+func unpackArguments(args []abi.Argument, data []byte) ([]interface{}, error) {
+	switch len(args) {
+	case 0:
+		return nil, nil
+	case 1:
+		var value interface{}
+		if err := (abi.ABI{Methods: map[string]abi.Method{"": {Outputs: args}}}).Unpack(&value, "", data); err != nil {
+			return nil, err
+		}
+		return []interface{}{value}, nil
+	}
+	var values []interface{}
+	if err := (abi.ABI{Methods: map[string]abi.Method{"": {Outputs: args}}}).Unpack(&values, "", data); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// argumentsMap keys values by the names of args. Unnamed arguments are keyed
+// by their position.
+func argumentsMap(args []abi.Argument, values []interface{}) map[string]interface{} {
+	m := make(map[string]interface{}, len(values))
+	for i, value := range values {
+		name := args[i].Name
+		if name == "" {
+			name = strconv.Itoa(i)
+		}
+		m[name] = value
+	}
+	return m
+}
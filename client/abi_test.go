@@ -0,0 +1,72 @@
+// Copyright 2017 AMIS Technologies
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package client
+
+import (
+	"math/big"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+const testABI = `[
+	{"type":"function","name":"balanceOf","constant":true,"inputs":[{"name":"owner","type":"address"}],"outputs":[{"name":"","type":"uint256"}]},
+	{"type":"function","name":"info","constant":true,"inputs":[],"outputs":[{"name":"owner","type":"address"},{"name":"name","type":"string"},{"name":"count","type":"uint256"}]},
+	{"type":"event","name":"Transfer","inputs":[{"name":"from","type":"address","indexed":true},{"name":"to","type":"address","indexed":true},{"name":"value","type":"uint256","indexed":false}]}
+]`
+
+func TestPackUnpackArguments(t *testing.T) {
+	parsed, err := abi.JSON(strings.NewReader(testABI))
+	if err != nil {
+		t.Fatal(err)
+	}
+	owner := common.HexToAddress("0x1234")
+	args := parsed.Methods["info"].Outputs
+	values := []interface{}{owner, "amis", big.NewInt(7)}
+
+	data, err := packArguments(args, values...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	have, err := unpackArguments(args, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(have, values) {
+		t.Errorf("values mismatch: have %v, want %v", have, values)
+	}
+	m := argumentsMap(args, have)
+	if m["name"] != "amis" || m["owner"] != owner {
+		t.Errorf("map mismatch: have %v", m)
+	}
+
+	args = parsed.Methods["balanceOf"].Outputs
+	data, _ = packArguments(args, big.NewInt(42))
+	have, err = unpackArguments(args, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(have) != 1 || have[0].(*big.Int).Int64() != 42 {
+		t.Errorf("value mismatch: have %v, want 42", have)
+	}
+	if m := argumentsMap(args, have); m["0"] == nil {
+		t.Errorf("unnamed output not keyed by position: %v", m)
+	}
+}
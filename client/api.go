@@ -24,14 +24,20 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/p2p"
+	ethrpc "github.com/ethereum/go-ethereum/rpc"
 )
 
 type Client interface {
 	Close()
+	BatchCallContext(ctx context.Context, b []ethrpc.BatchElem) error
 
 	// eth
 	BlockNumber(ctx context.Context) (*big.Int, error)
 	SendRawTransaction(ctx context.Context, tx *types.Transaction) error
+	ReplayTransaction(ctx context.Context, txHash common.Hash) ([]byte, error)
+	Sign(ctx context.Context, account common.Address, data []byte) ([]byte, error)
+	BlockWithReceipts(ctx context.Context, number *big.Int) (*BlockBundle, error)
+	BlockWithReceiptsByHash(ctx context.Context, hash common.Hash) (*BlockBundle, error)
 
 	// admin
 	AddPeer(ctx context.Context, nodeURL string) error
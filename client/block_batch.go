@@ -0,0 +1,178 @@
+// Copyright 2017 AMIS Technologies
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	ethrpc "github.com/ethereum/go-ethereum/rpc"
+)
+
+type rpcBlockBody struct {
+	Hash         common.Hash          `json:"hash"`
+	Transactions []*types.Transaction `json:"transactions"`
+	UncleHashes  []common.Hash        `json:"uncles"`
+}
+
+// batchBlocks fetches full blocks with method, eth_getBlockByNumber or
+// eth_getBlockByHash, for each of ids in a single batch, followed by a batch
+// for their uncles. It returns ethereum.NotFound if any block is missing.
+func batchBlocks(ctx context.Context, c Client, method string, ids []interface{}) ([]*types.Block, error) {
+	raws := make([]json.RawMessage, len(ids))
+	reqs := make([]ethrpc.BatchElem, len(ids))
+	for i, id := range ids {
+		reqs[i] = ethrpc.BatchElem{
+			Method: method,
+			Args:   []interface{}{id, true},
+			Result: &raws[i],
+		}
+	}
+	if err := c.BatchCallContext(ctx, reqs); err != nil {
+		return nil, err
+	}
+
+	var (
+		heads     = make([]*types.Header, len(ids))
+		bodies    = make([]rpcBlockBody, len(ids))
+		uncles    = make([][]*types.Header, len(ids))
+		uncleReqs []ethrpc.BatchElem
+	)
+	for i := range reqs {
+		if reqs[i].Error != nil {
+			return nil, reqs[i].Error
+		}
+		if len(raws[i]) == 0 || string(raws[i]) == "null" {
+			return nil, ethereum.NotFound
+		}
+		if err := json.Unmarshal(raws[i], &heads[i]); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(raws[i], &bodies[i]); err != nil {
+			return nil, err
+		}
+		uncles[i] = make([]*types.Header, len(bodies[i].UncleHashes))
+		for j := range bodies[i].UncleHashes {
+			uncleReqs = append(uncleReqs, ethrpc.BatchElem{
+				Method: "eth_getUncleByBlockHashAndIndex",
+				Args:   []interface{}{bodies[i].Hash, hexutil.EncodeUint64(uint64(j))},
+				Result: &uncles[i][j],
+			})
+		}
+	}
+	if len(uncleReqs) > 0 {
+		if err := c.BatchCallContext(ctx, uncleReqs); err != nil {
+			return nil, err
+		}
+		for _, req := range uncleReqs {
+			if req.Error != nil {
+				return nil, req.Error
+			}
+		}
+	}
+
+	blocks := make([]*types.Block, len(ids))
+	for i := range blocks {
+		for j, uncle := range uncles[i] {
+			if uncle == nil {
+				return nil, fmt.Errorf("got null header for uncle %d of block %x", j, bodies[i].Hash)
+			}
+		}
+		blocks[i] = types.NewBlockWithHeader(heads[i]).WithBody(bodies[i].Transactions, uncles[i])
+	}
+	return blocks, nil
+}
+
+// batchReceipts fetches the receipts of all transactions of blocks in a
+// single batch.
+func batchReceipts(ctx context.Context, c Client, blocks []*types.Block) ([][]*types.Receipt, error) {
+	var (
+		receipts = make([][]*types.Receipt, len(blocks))
+		reqs     []ethrpc.BatchElem
+	)
+	for i, block := range blocks {
+		receipts[i] = make([]*types.Receipt, len(block.Transactions()))
+		for j, tx := range block.Transactions() {
+			reqs = append(reqs, ethrpc.BatchElem{
+				Method: "eth_getTransactionReceipt",
+				Args:   []interface{}{tx.Hash()},
+				Result: &receipts[i][j],
+			})
+		}
+	}
+	if len(reqs) == 0 {
+		return receipts, nil
+	}
+	if err := c.BatchCallContext(ctx, reqs); err != nil {
+		return nil, err
+	}
+	for _, req := range reqs {
+		if req.Error != nil {
+			return nil, req.Error
+		}
+	}
+	for i, block := range blocks {
+		for j, receipt := range receipts[i] {
+			if receipt == nil {
+				return nil, fmt.Errorf("missing receipt of transaction %x in block %v", block.Transactions()[j].Hash(), block.Number())
+			}
+		}
+	}
+	return receipts, nil
+}
+
+// blockWithReceipts fetches a block with method, eth_getBlockByNumber or
+// eth_getBlockByHash, and all of its receipts in a single batch, and checks
+// the receipts against the block. Blocks with Quorum private transactions are
+// returned with PrivateReceipts set if their receipts do not match the
+// receipt root.
+func blockWithReceipts(ctx context.Context, c Client, method string, id interface{}) (*BlockBundle, error) {
+	blocks, err := batchBlocks(ctx, c, method, []interface{}{id})
+	if err != nil {
+		return nil, err
+	}
+	receipts, err := batchReceipts(ctx, c, blocks)
+	if err != nil {
+		return nil, err
+	}
+	bundle := &BlockBundle{Block: blocks[0], Receipts: receipts[0]}
+	if err := VerifyReceipts(blocks[0], receipts[0]); err != nil {
+		if !errors.Is(err, ErrReceiptRootMismatch) || !hasPrivateTxs(blocks[0]) {
+			return nil, err
+		}
+		bundle.PrivateReceipts = true
+	}
+	return bundle, nil
+}
+
+// hasPrivateTxs reports whether block holds transactions which may be Quorum
+// private ones. The chain ID is unknown here, so public chain 1 transactions
+// with the same V values are included, whose receipts match the root anyway.
+func hasPrivateTxs(block *types.Block) bool {
+	for _, tx := range block.Transactions() {
+		if hasPrivateV(tx) {
+			return true
+		}
+	}
+	return false
+}
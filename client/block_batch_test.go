@@ -0,0 +1,98 @@
+// Copyright 2017 AMIS Technologies
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package client
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func TestBlockWithReceipts(t *testing.T) {
+	chain := &FakeChain{}
+	newTestBlocks(t, chain, 10)
+	client := newTestNodeClient(t, chain)
+	ctx := context.Background()
+
+	block := chain.blocks[5]
+	for _, get := range []func() (*BlockBundle, error){
+		func() (*BlockBundle, error) { return client.BlockWithReceipts(ctx, big.NewInt(5)) },
+		func() (*BlockBundle, error) { return client.BlockWithReceiptsByHash(ctx, block.Hash()) },
+	} {
+		bundle, err := get()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if bundle.Block.Hash() != block.Hash() || len(bundle.Receipts) != len(block.Transactions()) {
+			t.Errorf("unexpected bundle: block %x with %d receipts", bundle.Block.Hash(), len(bundle.Receipts))
+		}
+	}
+
+	bundle, err := client.BlockWithReceipts(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bundle.Block.NumberU64() != 9 {
+		t.Errorf("unexpected latest block: %v", bundle.Block.Number())
+	}
+
+	// A receipt altered by the node no longer matches the receipt root.
+	tx := block.Transactions()[1]
+	altered := *chain.receipts[tx.Hash()]
+	altered.CumulativeGasUsed = big.NewInt(1)
+	chain.receipts[tx.Hash()] = &altered
+	if _, err := client.BlockWithReceipts(ctx, big.NewInt(5)); !errors.Is(err, ErrReceiptRootMismatch) {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	// Quorum returns the private receipts of private transactions, which
+	// differ from the public ones in the receipt root. Chain 1 signatures
+	// have the same V values as private transactions.
+	key, _ := crypto.GenerateKey()
+	private, _ := types.SignTx(types.NewTransaction(0, common.Address{}, common.Big1, big.NewInt(21000), common.Big1, nil), types.NewEIP155Signer(common.Big1), key)
+	public := types.NewReceipt(nil, false, big.NewInt(21000))
+	public.TxHash = private.Hash()
+	public.GasUsed = big.NewInt(21000)
+	public.Logs = []*types.Log{}
+	header := &types.Header{Number: big.NewInt(10), ParentHash: chain.blocks[9].Hash(), Difficulty: common.Big1, GasLimit: big.NewInt(4700000), Time: big.NewInt(1500000010)}
+	chain.blocks = append(chain.blocks, types.NewBlock(header, []*types.Transaction{private}, nil, []*types.Receipt{public}))
+	privateReceipt := *public
+	privateReceipt.CumulativeGasUsed = big.NewInt(0)
+	chain.receipts[private.Hash()] = &privateReceipt
+	bundle, err = client.BlockWithReceipts(ctx, big.NewInt(10))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bundle.PrivateReceipts || len(bundle.Receipts) != 1 {
+		t.Errorf("private receipts not flagged: %+v", bundle)
+	}
+	if bundle, err = client.BlockWithReceipts(ctx, big.NewInt(9)); err != nil || bundle.PrivateReceipts {
+		t.Errorf("unexpected bundle: %+v, %v", bundle, err)
+	}
+
+	if err := VerifyReceipts(block, []*types.Receipt{}); !errors.Is(err, ErrReceiptCountMismatch) {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if _, err := client.BlockWithReceiptsByHash(ctx, common.Hash{}); err == nil {
+		t.Error("expected error for unknown block")
+	}
+}
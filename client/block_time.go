@@ -0,0 +1,152 @@
+// Copyright 2017 AMIS Technologies
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package client
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// ErrBeforeGenesis is returned if a time is before the genesis block.
+var ErrBeforeGenesis = errors.New("time is before the genesis block")
+
+const (
+	// headerCacheSize is the number of headers kept by a BlockTimeFinder.
+	headerCacheSize = 4096
+	// headerCacheDepth is the number of blocks below the head that must exist
+	// before a header is cached, to avoid caching headers that may be reorged.
+	headerCacheDepth = 64
+)
+
+// BlockTimeFinder maps timestamps to block numbers. It makes no assumption
+// about the block time, so it works for PoW chains as well as Istanbul chains
+// with a fixed block period.
+type BlockTimeFinder struct {
+	client Client
+
+	mu    sync.Mutex
+	cache map[uint64]*types.Header
+}
+
+// NewBlockTimeFinder creates a finder reading headers through client.
+func NewBlockTimeFinder(client Client) *BlockTimeFinder {
+	return &BlockTimeFinder{
+		client: client,
+		cache:  make(map[uint64]*types.Header),
+	}
+}
+
+// BlockNumberAt returns the number of the last block with a timestamp at or
+// before t. It runs a binary search over block headers, interpolating the
+// guess from the timestamps at the bounds.
+func (f *BlockTimeFinder) BlockNumberAt(ctx context.Context, t time.Time) (*big.Int, error) {
+	target := t.Unix()
+
+	latest, err := f.client.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	if latest.Time.Int64() <= target {
+		return latest.Number, nil
+	}
+	head := latest.Number.Uint64()
+	genesis, err := f.header(ctx, 0, head)
+	if err != nil {
+		return nil, err
+	}
+	if genesis.Time.Int64() > target {
+		return nil, ErrBeforeGenesis
+	}
+
+	// Invariant: lo.Time <= target < hi.Time
+	lo, hi := genesis, latest
+	for bisect := false; hi.Number.Uint64()-lo.Number.Uint64() > 1; bisect = !bisect {
+		loNum, hiNum := lo.Number.Uint64(), hi.Number.Uint64()
+		guess := loNum + (hiNum-loNum)/2
+		// Alternating with plain bisection bounds the number of steps when
+		// block times are irregular.
+		if !bisect {
+			span := hi.Time.Int64() - lo.Time.Int64()
+			guess = loNum + uint64(float64(hiNum-loNum)*float64(target-lo.Time.Int64())/float64(span))
+		}
+		if guess <= loNum {
+			guess = loNum + 1
+		} else if guess >= hiNum {
+			guess = hiNum - 1
+		}
+		header, err := f.header(ctx, guess, head)
+		if err != nil {
+			return nil, err
+		}
+		if header.Time.Int64() <= target {
+			lo = header
+		} else {
+			hi = header
+		}
+	}
+	return lo.Number, nil
+}
+
+// BalanceAt returns the balance of account at the last block at or before t.
+func (f *BlockTimeFinder) BalanceAt(ctx context.Context, account common.Address, t time.Time) (*big.Int, error) {
+	number, err := f.BlockNumberAt(ctx, t)
+	if err != nil {
+		return nil, err
+	}
+	return f.client.BalanceAt(ctx, account, number)
+}
+
+// CallContract executes msg on the state of the last block at or before t.
+func (f *BlockTimeFinder) CallContract(ctx context.Context, msg ethereum.CallMsg, t time.Time) ([]byte, error) {
+	number, err := f.BlockNumberAt(ctx, t)
+	if err != nil {
+		return nil, err
+	}
+	return f.client.CallContract(ctx, msg, number)
+}
+
+// header returns the header of block number, from the cache if possible.
+// Headers at least headerCacheDepth blocks below head are cached.
+func (f *BlockTimeFinder) header(ctx context.Context, number, head uint64) (*types.Header, error) {
+	f.mu.Lock()
+	header, ok := f.cache[number]
+	f.mu.Unlock()
+	if ok {
+		return header, nil
+	}
+
+	header, err := f.client.HeaderByNumber(ctx, new(big.Int).SetUint64(number))
+	if err != nil {
+		return nil, err
+	}
+	if number+headerCacheDepth <= head {
+		f.mu.Lock()
+		if len(f.cache) >= headerCacheSize {
+			f.cache = make(map[uint64]*types.Header)
+		}
+		f.cache[number] = header
+		f.mu.Unlock()
+	}
+	return header, nil
+}
@@ -0,0 +1,88 @@
+// Copyright 2017 AMIS Technologies
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package client
+
+import (
+	"context"
+	"math/big"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// newTestChain returns headers starting at genesis time start, with block
+// times given by period.
+func newTestChain(n int, start int64, period func(i int) int64) []*types.Header {
+	headers := make([]*types.Header, n)
+	t := start
+	for i := range headers {
+		if i > 0 {
+			t += period(i)
+		}
+		headers[i] = &types.Header{
+			Number:     big.NewInt(int64(i)),
+			Time:       big.NewInt(t),
+			Difficulty: common.Big1,
+			GasLimit:   big.NewInt(4700000),
+			GasUsed:    new(big.Int),
+		}
+		if i > 0 {
+			headers[i].ParentHash = headers[i-1].Hash()
+		}
+	}
+	return headers
+}
+
+func TestBlockNumberAt(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	chains := map[string][]*types.Header{
+		"pow":      newTestChain(2000, 1500000000, func(int) int64 { return 1 + rnd.Int63n(30) }),
+		"istanbul": newTestChain(2000, 1500000000, func(int) int64 { return 1 }),
+		"stalled": newTestChain(2000, 1500000000, func(i int) int64 {
+			if i == 1500 {
+				return 100000
+			}
+			return 1
+		}),
+	}
+	for name, headers := range chains {
+		finder := NewBlockTimeFinder(newTestNodeClient(t, &FakeChain{headers: headers}))
+		first, last := headers[0].Time.Int64(), headers[len(headers)-1].Time.Int64()
+
+		if _, err := finder.BlockNumberAt(context.Background(), time.Unix(first-1, 0)); err != ErrBeforeGenesis {
+			t.Errorf("%s: unexpected error: %v", name, err)
+		}
+		for i := 0; i < 50; i++ {
+			target := first + rnd.Int63n(last-first+100)
+			// The expected block is found with a linear scan.
+			want := 0
+			for want+1 < len(headers) && headers[want+1].Time.Int64() <= target {
+				want++
+			}
+			have, err := finder.BlockNumberAt(context.Background(), time.Unix(target, 0))
+			if err != nil {
+				t.Fatalf("%s: %v", name, err)
+			}
+			if have.Int64() != int64(want) {
+				t.Errorf("%s: time %d: have block %v, want %d", name, target, have, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,482 @@
+// Copyright 2017 AMIS Technologies
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// ErrNoMetadata is returned if the bytecode has no solc metadata appended.
+var ErrNoMetadata = errors.New("no solc metadata")
+
+// ProxyKind is a known proxy pattern.
+type ProxyKind int
+
+const (
+	// NotProxy means no proxy pattern was detected.
+	NotProxy ProxyKind = iota
+	// MinimalProxy is an EIP-1167 clone with a hard coded implementation.
+	MinimalProxy
+	// EIP1967Proxy stores its implementation in the EIP-1967 slot.
+	EIP1967Proxy
+	// EIP1967BeaconProxy stores a beacon address in the EIP-1967 beacon slot.
+	EIP1967BeaconProxy
+	// EIP1822Proxy stores its implementation in the EIP-1822 PROXIABLE slot.
+	EIP1822Proxy
+	// ZeppelinOSProxy stores its implementation in the legacy ZeppelinOS slot.
+	ZeppelinOSProxy
+	// DelegateProxy delegates calls to an unknown location.
+	DelegateProxy
+)
+
+func (k ProxyKind) String() string {
+	switch k {
+	case NotProxy:
+		return "none"
+	case MinimalProxy:
+		return "eip1167"
+	case EIP1967Proxy:
+		return "eip1967"
+	case EIP1967BeaconProxy:
+		return "eip1967-beacon"
+	case EIP1822Proxy:
+		return "eip1822"
+	case ZeppelinOSProxy:
+		return "zeppelinos"
+	case DelegateProxy:
+		return "delegatecall"
+	default:
+		return fmt.Sprintf("ProxyKind(%d)", int(k))
+	}
+}
+
+var (
+	// EIP1967ImplementationSlot is bytes32(uint256(keccak256('eip1967.proxy.implementation')) - 1).
+	EIP1967ImplementationSlot = eip1967Slot("eip1967.proxy.implementation")
+	// EIP1967BeaconSlot is bytes32(uint256(keccak256('eip1967.proxy.beacon')) - 1).
+	EIP1967BeaconSlot = eip1967Slot("eip1967.proxy.beacon")
+	// EIP1822Slot is keccak256('PROXIABLE').
+	EIP1822Slot = crypto.Keccak256Hash([]byte("PROXIABLE"))
+	// ZeppelinOSImplementationSlot is keccak256('org.zeppelinos.proxy.implementation').
+	ZeppelinOSImplementationSlot = crypto.Keccak256Hash([]byte("org.zeppelinos.proxy.implementation"))
+
+	// The EIP-1167 minimal proxy code surrounding the implementation address.
+	minimalProxyPrefix = common.FromHex("0x363d3d373d3d3d363d73")
+	minimalProxySuffix = common.FromHex("0x5af43d82803e903d91602b57fd5bf3")
+
+	proxySlots = []struct {
+		kind ProxyKind
+		slot common.Hash
+	}{
+		{EIP1967Proxy, EIP1967ImplementationSlot},
+		{EIP1967BeaconProxy, EIP1967BeaconSlot},
+		{EIP1822Proxy, EIP1822Slot},
+		{ZeppelinOSProxy, ZeppelinOSImplementationSlot},
+	}
+)
+
+// EVM opcodes used by the analysis.
+const (
+	opEQ           = 0x14
+	opPUSH1        = 0x60
+	opPUSH4        = 0x63
+	opPUSH32       = 0x7f
+	opDUP1         = 0x80
+	opDUP16        = 0x8f
+	opDELEGATECALL = 0xf4
+)
+
+func eip1967Slot(name string) common.Hash {
+	return common.BigToHash(new(big.Int).Sub(crypto.Keccak256Hash([]byte(name)).Big(), common.Big1))
+}
+
+// Metadata is the solc metadata appended to runtime bytecode.
+type Metadata struct {
+	// Swarm is the swarm hash of the metadata file, if present.
+	Swarm common.Hash
+	// SwarmVersion is the key the swarm hash is stored under, bzzr0 or bzzr1.
+	SwarmVersion string
+	// IPFS is the IPFS multihash of the metadata file, if present.
+	IPFS []byte
+	// Solc is the compiler version, if present.
+	Solc string
+	// Experimental is set if experimental compiler features were used.
+	Experimental bool
+	// Raw is the CBOR encoded metadata including the length suffix.
+	Raw []byte
+}
+
+// StripMetadata removes the CBOR encoded metadata solc appends to runtime
+// bytecode, such as the bzzr0 swarm hash. Code without metadata is returned
+// unchanged.
+func StripMetadata(code []byte) []byte {
+	code, _ = splitMetadata(code)
+	return code
+}
+
+// DecodeMetadata decodes the solc metadata appended to runtime bytecode.
+func DecodeMetadata(code []byte) (*Metadata, error) {
+	_, raw := splitMetadata(code)
+	if raw == nil {
+		return nil, ErrNoMetadata
+	}
+	entries, err := decodeCBORMap(raw[:len(raw)-2])
+	if err != nil {
+		return nil, err
+	}
+	meta := &Metadata{Raw: raw}
+	for key, value := range entries {
+		switch key {
+		case "bzzr0", "bzzr1":
+			hash, ok := value.([]byte)
+			if !ok || len(hash) != common.HashLength {
+				return nil, fmt.Errorf("invalid %s metadata entry", key)
+			}
+			meta.Swarm = common.BytesToHash(hash)
+			meta.SwarmVersion = key
+		case "ipfs":
+			hash, ok := value.([]byte)
+			if !ok {
+				return nil, errors.New("invalid ipfs metadata entry")
+			}
+			meta.IPFS = hash
+		case "solc":
+			switch v := value.(type) {
+			case []byte:
+				if len(v) == 3 {
+					meta.Solc = fmt.Sprintf("%d.%d.%d", v[0], v[1], v[2])
+				} else {
+					meta.Solc = string(v)
+				}
+			case string:
+				meta.Solc = v
+			}
+		case "experimental":
+			meta.Experimental, _ = value.(bool)
+		}
+	}
+	return meta, nil
+}
+
+// splitMetadata splits code into the executable part and the metadata
+// including its two byte length suffix. The metadata is nil if not present.
+func splitMetadata(code []byte) ([]byte, []byte) {
+	if len(code) < 2 {
+		return code, nil
+	}
+	// The last two bytes hold the length of the CBOR map preceding them.
+	size := int(binary.BigEndian.Uint16(code[len(code)-2:]))
+	start := len(code) - 2 - size
+	if size == 0 || start < 0 {
+		return code, nil
+	}
+	// Code which merely ends like a length suffix is left alone.
+	if _, err := decodeCBORMap(code[start : len(code)-2]); err != nil {
+		return code, nil
+	}
+	return code[:start], code[start:]
+}
+
+// decodeCBORMap decodes the subset of CBOR used by solc: a map of text keys
+// to byte string, text string or boolean values.
+func decodeCBORMap(data []byte) (map[string]interface{}, error) {
+	major, count, rest, err := readCBORHead(data)
+	if err != nil {
+		return nil, err
+	}
+	if major != 5 {
+		return nil, errors.New("metadata is not a CBOR map")
+	}
+	entries := make(map[string]interface{})
+	for i := uint64(0); i < count; i++ {
+		var key, value interface{}
+		if key, rest, err = readCBORItem(rest); err != nil {
+			return nil, err
+		}
+		if value, rest, err = readCBORItem(rest); err != nil {
+			return nil, err
+		}
+		name, ok := key.(string)
+		if !ok {
+			return nil, errors.New("metadata key is not a string")
+		}
+		entries[name] = value
+	}
+	if len(rest) != 0 {
+		return nil, errors.New("trailing bytes after metadata")
+	}
+	return entries, nil
+}
+
+func readCBORItem(data []byte) (interface{}, []byte, error) {
+	major, arg, rest, err := readCBORHead(data)
+	if err != nil {
+		return nil, nil, err
+	}
+	switch major {
+	case 2, 3:
+		if uint64(len(rest)) < arg {
+			return nil, nil, errors.New("truncated metadata")
+		}
+		value := common.CopyBytes(rest[:arg])
+		if major == 3 {
+			return string(value), rest[arg:], nil
+		}
+		return value, rest[arg:], nil
+	case 7:
+		switch arg {
+		case 20:
+			return false, rest, nil
+		case 21:
+			return true, rest, nil
+		}
+	}
+	return nil, nil, fmt.Errorf("unsupported metadata item type %d", major)
+}
+
+func readCBORHead(data []byte) (byte, uint64, []byte, error) {
+	if len(data) == 0 {
+		return 0, 0, nil, errors.New("truncated metadata")
+	}
+	major, info, data := data[0]>>5, data[0]&0x1f, data[1:]
+	switch {
+	case info < 24:
+		return major, uint64(info), data, nil
+	case info == 24 && len(data) >= 1:
+		return major, uint64(data[0]), data[1:], nil
+	case info == 25 && len(data) >= 2:
+		return major, uint64(binary.BigEndian.Uint16(data)), data[2:], nil
+	case info == 26 && len(data) >= 4:
+		return major, uint64(binary.BigEndian.Uint32(data)), data[4:], nil
+	}
+	return 0, 0, nil, errors.New("unsupported or truncated metadata item")
+}
+
+// Selectors returns the function selectors compared against in the
+// dispatcher of runtime bytecode, in order of appearance. A selector is a
+// PUSH4 value followed by an EQ, possibly with a DUP in between.
+func Selectors(code []byte) [][4]byte {
+	code = StripMetadata(code)
+	var (
+		selectors [][4]byte
+		seen      = make(map[[4]byte]bool)
+	)
+	for pc := 0; pc < len(code); pc = nextInstruction(code, pc) {
+		if code[pc] != opPUSH4 || pc+5 > len(code) {
+			continue
+		}
+		var sel [4]byte
+		copy(sel[:], code[pc+1:pc+5])
+		next := pc + 5
+		if next < len(code) && code[next] >= opDUP1 && code[next] <= opDUP16 {
+			next++
+		}
+		if next < len(code) && code[next] == opEQ && !seen[sel] {
+			seen[sel] = true
+			selectors = append(selectors, sel)
+		}
+	}
+	return selectors
+}
+
+// nextInstruction returns the position of the instruction after the one at
+// pc, skipping push data.
+func nextInstruction(code []byte, pc int) int {
+	if op := code[pc]; op >= opPUSH1 && op <= opPUSH32 {
+		return pc + 2 + int(op-opPUSH1)
+	}
+	return pc + 1
+}
+
+// ProxyInfo describes the proxy pattern detected in runtime bytecode.
+type ProxyInfo struct {
+	Kind ProxyKind
+	// Slot is the storage slot holding the implementation or beacon, for
+	// slot based proxies.
+	Slot common.Hash
+	// Implementation is the address calls are delegated to, if known.
+	Implementation common.Address
+}
+
+// DetectProxy detects common proxy patterns in runtime bytecode. The
+// implementation of slot based proxies is only known after reading the slot,
+// see CodeAnalyzer.
+func DetectProxy(code []byte) *ProxyInfo {
+	if len(code) == len(minimalProxyPrefix)+common.AddressLength+len(minimalProxySuffix) &&
+		bytes.HasPrefix(code, minimalProxyPrefix) && bytes.HasSuffix(code, minimalProxySuffix) {
+		return &ProxyInfo{
+			Kind:           MinimalProxy,
+			Implementation: common.BytesToAddress(code[len(minimalProxyPrefix) : len(minimalProxyPrefix)+common.AddressLength]),
+		}
+	}
+
+	code = StripMetadata(code)
+	delegates := false
+	for pc := 0; pc < len(code); pc = nextInstruction(code, pc) {
+		op := code[pc]
+		if op == opDELEGATECALL {
+			delegates = true
+		}
+		if op != opPUSH32 || pc+33 > len(code) {
+			continue
+		}
+		value := common.BytesToHash(code[pc+1 : pc+33])
+		for _, p := range proxySlots {
+			if value == p.slot {
+				return &ProxyInfo{Kind: p.kind, Slot: p.slot}
+			}
+		}
+	}
+	if delegates {
+		return &ProxyInfo{Kind: DelegateProxy}
+	}
+	return &ProxyInfo{Kind: NotProxy}
+}
+
+// Bytecode is the result of analyzing runtime bytecode.
+type Bytecode struct {
+	Code []byte
+	// Metadata is nil if the code has no solc metadata.
+	Metadata  *Metadata
+	Selectors [][4]byte
+	Proxy     *ProxyInfo
+}
+
+// AnalyzeBytecode analyzes runtime bytecode.
+func AnalyzeBytecode(code []byte) *Bytecode {
+	meta, _ := DecodeMetadata(code)
+	return &Bytecode{
+		Code:      code,
+		Metadata:  meta,
+		Selectors: Selectors(code),
+		Proxy:     DetectProxy(code),
+	}
+}
+
+// CodeAnalyzer analyzes the runtime bytecode of deployed contracts.
+type CodeAnalyzer struct {
+	client Client
+}
+
+// NewCodeAnalyzer creates an analyzer reading code through client.
+func NewCodeAnalyzer(client Client) *CodeAnalyzer {
+	return &CodeAnalyzer{
+		client: client,
+	}
+}
+
+// Analyze analyzes the code at address at the given block, or the latest
+// block if blockNumber is nil. The implementation of slot based proxies is
+// read from storage at the same block; for beacon proxies it is the beacon
+// address.
+func (a *CodeAnalyzer) Analyze(ctx context.Context, address common.Address, blockNumber *big.Int) (*Bytecode, error) {
+	code, err := a.client.CodeAt(ctx, address, blockNumber)
+	if err != nil {
+		return nil, err
+	}
+	if len(code) == 0 {
+		return nil, ErrNoCode
+	}
+	result := AnalyzeBytecode(code)
+	switch result.Proxy.Kind {
+	case EIP1967Proxy, EIP1967BeaconProxy, EIP1822Proxy, ZeppelinOSProxy:
+		value, err := a.client.StorageAt(ctx, address, result.Proxy.Slot, blockNumber)
+		if err != nil {
+			return nil, err
+		}
+		result.Proxy.Implementation = common.BytesToAddress(value)
+	}
+	return result, nil
+}
+
+// CodeDiff is the result of comparing two runtime bytecodes.
+type CodeDiff struct {
+	// Identical is set if the code is byte for byte identical.
+	Identical bool
+	// SameCode is set if the code is identical ignoring the metadata.
+	SameCode bool
+	// ImplementationChanged is set if both are proxies delegating to
+	// different implementations.
+	ImplementationChanged bool
+	// Added and Removed are the dispatcher selectors only present in the
+	// second and the first code respectively.
+	Added   [][4]byte
+	Removed [][4]byte
+}
+
+// Upgraded reports whether the executed code differs.
+func (d *CodeDiff) Upgraded() bool {
+	return !d.SameCode || d.ImplementationChanged
+}
+
+// CompareBytecode compares two analyzed bytecodes.
+func CompareBytecode(a, b *Bytecode) *CodeDiff {
+	diff := &CodeDiff{
+		Identical: bytes.Equal(a.Code, b.Code),
+		SameCode:  bytes.Equal(StripMetadata(a.Code), StripMetadata(b.Code)),
+		ImplementationChanged: a.Proxy.Kind != NotProxy && a.Proxy.Kind == b.Proxy.Kind &&
+			a.Proxy.Implementation != b.Proxy.Implementation,
+		Added:   selectorsDiff(b.Selectors, a.Selectors),
+		Removed: selectorsDiff(a.Selectors, b.Selectors),
+	}
+	return diff
+}
+
+// selectorsDiff returns the selectors in a that are not in b, sorted.
+func selectorsDiff(a, b [][4]byte) [][4]byte {
+	known := make(map[[4]byte]bool)
+	for _, sel := range b {
+		known[sel] = true
+	}
+	var diff selectorList
+	for _, sel := range a {
+		if !known[sel] {
+			diff = append(diff, sel)
+		}
+	}
+	sort.Sort(diff)
+	return diff
+}
+
+type selectorList [][4]byte
+
+func (s selectorList) Len() int           { return len(s) }
+func (s selectorList) Less(i, j int) bool { return bytes.Compare(s[i][:], s[j][:]) < 0 }
+func (s selectorList) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+
+// Compare compares the code at addressA at blockA with the code at
+// addressB at blockB, e.g. the same proxy before and after an upgrade.
+func (a *CodeAnalyzer) Compare(ctx context.Context, addressA common.Address, blockA *big.Int, addressB common.Address, blockB *big.Int) (*CodeDiff, error) {
+	codeA, err := a.Analyze(ctx, addressA, blockA)
+	if err != nil {
+		return nil, err
+	}
+	codeB, err := a.Analyze(ctx, addressB, blockB)
+	if err != nil {
+		return nil, err
+	}
+	return CompareBytecode(codeA, codeB), nil
+}
@@ -0,0 +1,136 @@
+// Copyright 2017 AMIS Technologies
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package client
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// bzzr0Metadata is the metadata solc 0.4 appends for swarm hash 0x11..11.
+var bzzr0Metadata = common.FromHex("0xa165627a7a72305820" + "1111111111111111111111111111111111111111111111111111111111111111" + "0029")
+
+func TestMetadata(t *testing.T) {
+	code := common.FromHex("0x6060604052")
+	withMeta := append(common.CopyBytes(code), bzzr0Metadata...)
+
+	if have := StripMetadata(withMeta); !bytes.Equal(have, code) {
+		t.Errorf("unexpected stripped code: %x", have)
+	}
+	if have := StripMetadata(code); !bytes.Equal(have, code) {
+		t.Errorf("code without metadata changed: %x", have)
+	}
+	meta, err := DecodeMetadata(withMeta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if meta.SwarmVersion != "bzzr0" || meta.Swarm != common.HexToHash("0x1111111111111111111111111111111111111111111111111111111111111111") {
+		t.Errorf("unexpected swarm hash: %s %x", meta.SwarmVersion, meta.Swarm)
+	}
+	if _, err := DecodeMetadata(code); err != ErrNoMetadata {
+		t.Errorf("unexpected error: %v", err)
+	}
+	// A tail which only looks like a map header and length suffix is code.
+	fake := append(common.CopyBytes(code), 0xa1, 0x00, 0x00, 0x00, 0x03)
+	if have := StripMetadata(fake); !bytes.Equal(have, fake) {
+		t.Errorf("code without metadata changed: %x", have)
+	}
+
+	// solc >= 0.5.9 style with ipfs and a version
+	modern := common.FromHex("0xa264697066735822" + "1220" + "2222222222222222222222222222222222222222222222222222222222222222" + "64736f6c6343" + "00050c" + "0033")
+	meta, err = DecodeMetadata(append(common.CopyBytes(code), modern...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if meta.Solc != "0.5.12" || len(meta.IPFS) != 34 {
+		t.Errorf("unexpected metadata: %+v", meta)
+	}
+}
+
+func TestSelectors(t *testing.T) {
+	// A solc 0.4 style dispatcher for transfer and balanceOf, with a PUSH32
+	// containing a PUSH4 byte that must not be mistaken for an instruction.
+	code := common.FromHex("0x6060604052600035" +
+		"7c0100000000000000000000000000000000000000000000000000000000900463ffffffff16" +
+		"8063a9059cbb14610046578063" + "70a08231" + "14610050575b" +
+		"7f6311223344140000000000000000000000000000000000000000000000000000" + "00")
+	code = append(code, bzzr0Metadata...)
+
+	have := Selectors(code)
+	want := [][4]byte{{0xa9, 0x05, 0x9c, 0xbb}, {0x70, 0xa0, 0x82, 0x31}}
+	if len(have) != len(want) {
+		t.Fatalf("unexpected selectors: %x", have)
+	}
+	for i := range want {
+		if have[i] != want[i] {
+			t.Errorf("selector %d: have %x, want %x", i, have[i], want[i])
+		}
+	}
+}
+
+func TestDetectProxy(t *testing.T) {
+	impl := common.HexToAddress("0xbebebebebebebebebebebebebebebebebebebebe")
+	if EIP1967ImplementationSlot != common.HexToHash("0x360894a13ba1a3210667c828492db98dca3e2076cc3735a920a3ca505d382bbc") {
+		t.Fatalf("unexpected EIP-1967 slot: %x", EIP1967ImplementationSlot)
+	}
+
+	tests := []struct {
+		code []byte
+		kind ProxyKind
+		impl common.Address
+	}{
+		{
+			common.FromHex("0x363d3d373d3d3d363d73bebebebebebebebebebebebebebebebebebebebe5af43d82803e903d91602b57fd5bf3"),
+			MinimalProxy, impl,
+		},
+		{
+			append(append([]byte{opPUSH32}, EIP1967ImplementationSlot.Bytes()...), 0x54, opDELEGATECALL),
+			EIP1967Proxy, common.Address{},
+		},
+		{
+			[]byte{0x36, 0x60, 0x00, 0x80, 0x37, opDELEGATECALL},
+			DelegateProxy, common.Address{},
+		},
+		{
+			common.FromHex("0x6060604052"),
+			NotProxy, common.Address{},
+		},
+	}
+	for _, test := range tests {
+		proxy := DetectProxy(test.code)
+		if proxy.Kind != test.kind || proxy.Implementation != test.impl {
+			t.Errorf("%x: have %v %x, want %v %x", test.code, proxy.Kind, proxy.Implementation, test.kind, test.impl)
+		}
+	}
+}
+
+func TestCompareBytecode(t *testing.T) {
+	v1 := common.FromHex("0x8063a9059cbb14")
+	v2 := common.FromHex("0x8063a9059cbb148063095ea7b314")
+	meta := append(common.CopyBytes(v1), bzzr0Metadata...)
+
+	diff := CompareBytecode(AnalyzeBytecode(v1), AnalyzeBytecode(meta))
+	if diff.Identical || !diff.SameCode || diff.Upgraded() {
+		t.Errorf("unexpected diff for metadata change: %+v", diff)
+	}
+	diff = CompareBytecode(AnalyzeBytecode(v1), AnalyzeBytecode(v2))
+	if !diff.Upgraded() || len(diff.Added) != 1 || diff.Added[0] != [4]byte{0x09, 0x5e, 0xa7, 0xb3} || len(diff.Removed) != 0 {
+		t.Errorf("unexpected diff for upgrade: %+v", diff)
+	}
+}
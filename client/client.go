@@ -18,8 +18,11 @@ package client
 
 import (
 	"context"
+	"errors"
 	"math/big"
 
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/ethclient"
@@ -55,6 +58,13 @@ func (c *client) Close() {
 	c.rpc.Close()
 }
 
+// BatchCallContext sends all given requests as a single batch and waits for the server
+// to return a response for all of them. Errors specific to a request are reported through
+// the Error field of the corresponding BatchElem.
+func (c *client) BatchCallContext(ctx context.Context, b []ethrpc.BatchElem) error {
+	return c.rpc.BatchCallContext(ctx, b)
+}
+
 // ----------------------------------------------------------------------------
 // eth
 
@@ -66,17 +76,129 @@ func (c *client) SendRawTransaction(ctx context.Context, tx *types.Transaction)
 	return c.SendTransaction(ctx, tx)
 }
 
+// SendTransaction injects a signed transaction into the pending pool for execution.
+// Rejections by the node are returned as the typed errors of this package.
+func (c *client) SendTransaction(ctx context.Context, tx *types.Transaction) error {
+	return ClassifyError(c.Client.SendTransaction(ctx, tx))
+}
+
 // BlockNumber returns the current block number.
 func (c *client) BlockNumber(ctx context.Context) (*big.Int, error) {
 	var r string
 	err := c.rpc.CallContext(ctx, &r, "eth_blockNumber")
 	if err != nil {
-		return nil, err
+		return nil, ClassifyError(err)
 	}
 	h, err := hexutil.DecodeBig(r)
 	return h, err
 }
 
+// CallContract executes a message call transaction, which is directly executed in the VM
+// of the node, but never mined into the blockchain. A reverted call returns a *RevertError,
+// other failures are classified with ClassifyError.
+func (c *client) CallContract(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	result, err := c.Client.CallContract(ctx, msg, blockNumber)
+	if err != nil {
+		return nil, decodeCallError(err)
+	}
+	return result, nil
+}
+
+// PendingCallContract executes a message call transaction using the EVM against the
+// pending state. A reverted call returns a *RevertError, other failures are classified
+// with ClassifyError.
+func (c *client) PendingCallContract(ctx context.Context, msg ethereum.CallMsg) ([]byte, error) {
+	result, err := c.Client.PendingCallContract(ctx, msg)
+	if err != nil {
+		return nil, decodeCallError(err)
+	}
+	return result, nil
+}
+
+// EstimateGas tries to estimate the gas needed to execute a specific transaction based on
+// the current pending state of the backend blockchain. A reverted execution returns a
+// *RevertError, other failures are classified with ClassifyError.
+func (c *client) EstimateGas(ctx context.Context, msg ethereum.CallMsg) (*big.Int, error) {
+	gas, err := c.Client.EstimateGas(ctx, msg)
+	if err != nil {
+		return nil, decodeCallError(err)
+	}
+	return gas, nil
+}
+
+// decodeCallError returns the revert of a call as a *RevertError, and
+// classifies any other failure.
+func decodeCallError(err error) error {
+	err = DecodeRevert(err)
+	if _, ok := err.(*RevertError); ok {
+		return err
+	}
+	return ClassifyError(err)
+}
+
+// ReplayTransaction re-executes a mined transaction as a call on the state of its parent
+// block. It is mainly used to recover the revert reason of a failed transaction, which is
+// returned as a *RevertError.
+func (c *client) ReplayTransaction(ctx context.Context, txHash common.Hash) ([]byte, error) {
+	var tx *struct {
+		From        common.Address  `json:"from"`
+		To          *common.Address `json:"to"`
+		Gas         *hexutil.Big    `json:"gas"`
+		GasPrice    *hexutil.Big    `json:"gasPrice"`
+		Value       *hexutil.Big    `json:"value"`
+		Input       hexutil.Bytes   `json:"input"`
+		BlockNumber *hexutil.Big    `json:"blockNumber"`
+	}
+	err := c.rpc.CallContext(ctx, &tx, "eth_getTransactionByHash", txHash)
+	if err != nil {
+		return nil, ClassifyError(err)
+	}
+	if tx == nil {
+		return nil, ethereum.NotFound
+	}
+	if tx.BlockNumber == nil {
+		return nil, errors.New("transaction is pending")
+	}
+	msg := ethereum.CallMsg{
+		From:     tx.From,
+		To:       tx.To,
+		Gas:      tx.Gas.ToInt(),
+		GasPrice: tx.GasPrice.ToInt(),
+		Value:    tx.Value.ToInt(),
+		Data:     tx.Input,
+	}
+	parent := new(big.Int).Sub(tx.BlockNumber.ToInt(), common.Big1)
+	return c.CallContract(ctx, msg, parent)
+}
+
+// Sign signs data with an account held by the node using eth_sign, which applies the
+// personal message prefix to data before signing.
+func (c *client) Sign(ctx context.Context, account common.Address, data []byte) ([]byte, error) {
+	var sig hexutil.Bytes
+	err := c.rpc.CallContext(ctx, &sig, "eth_sign", account, hexutil.Bytes(data))
+	if err != nil {
+		return nil, ClassifyError(err)
+	}
+	return sig, nil
+}
+
+// BlockWithReceipts returns the block with the given number, or the latest block if number
+// is nil, together with the receipts of all its transactions. The receipts are fetched in a
+// single batch and checked against the receipt root of the block.
+func (c *client) BlockWithReceipts(ctx context.Context, number *big.Int) (*BlockBundle, error) {
+	id := "latest"
+	if number != nil {
+		id = hexutil.EncodeBig(number)
+	}
+	return blockWithReceipts(ctx, c, "eth_getBlockByNumber", id)
+}
+
+// BlockWithReceiptsByHash returns the block with the given hash together with the receipts
+// of all its transactions, see BlockWithReceipts.
+func (c *client) BlockWithReceiptsByHash(ctx context.Context, hash common.Hash) (*BlockBundle, error) {
+	return blockWithReceipts(ctx, c, "eth_getBlockByHash", hash)
+}
+
 // ----------------------------------------------------------------------------
 // admin
 
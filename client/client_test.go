@@ -16,7 +16,255 @@
 
 package client
 
-// Verfiy that client implements the Client interface.
+import (
+	"crypto/ecdsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync/atomic"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+	ethrpc "github.com/ethereum/go-ethereum/rpc"
+)
+
+// Verify that client implements the Client interface.
 var (
 	_ = Client(&client{})
 )
+
+func newTestNodeClient(t *testing.T, services ...interface{}) Client {
+	srv := ethrpc.NewServer()
+	for _, service := range services {
+		if err := srv.RegisterName("eth", service); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return NewClient(ethrpc.DialInProc(srv))
+}
+
+// FakeTxPool is a stand-in for the transaction handling of a node.
+type FakeTxPool struct {
+	sent     []common.Hash
+	pool     map[common.Hash]*types.Transaction
+	receipts map[common.Hash]*types.Receipt
+	nonce    uint64
+	// nonces overrides nonce for the accounts it contains.
+	nonces   map[common.Address]uint64
+	gasPrice int64
+}
+
+func (p *FakeTxPool) SendRawTransaction(raw hexutil.Bytes) (common.Hash, error) {
+	tx := new(types.Transaction)
+	if err := rlp.DecodeBytes(raw, tx); err != nil {
+		return common.Hash{}, err
+	}
+	p.sent = append(p.sent, tx.Hash())
+	if p.pool == nil {
+		p.pool = make(map[common.Hash]*types.Transaction)
+	}
+	p.pool[tx.Hash()] = tx
+	return tx.Hash(), nil
+}
+
+// GetTransactionByHash returns a pooled transaction, which is reported as
+// mined once it has a receipt.
+func (p *FakeTxPool) GetTransactionByHash(hash common.Hash) interface{} {
+	tx, ok := p.pool[hash]
+	if !ok {
+		return nil
+	}
+	fields := make(map[string]interface{})
+	data, _ := json.Marshal(tx)
+	json.Unmarshal(data, &fields)
+	if _, ok := p.receipts[hash]; ok {
+		fields["blockNumber"] = "0x1"
+	}
+	return fields
+}
+
+func (p *FakeTxPool) GetTransactionReceipt(hash common.Hash) *types.Receipt {
+	return p.receipts[hash]
+}
+
+func (p *FakeTxPool) GetTransactionCount(account common.Address, block string) hexutil.Uint64 {
+	if nonce, ok := p.nonces[account]; ok {
+		return hexutil.Uint64(nonce)
+	}
+	return hexutil.Uint64(p.nonce)
+}
+
+func (p *FakeTxPool) GasPrice() *hexutil.Big {
+	return (*hexutil.Big)(big.NewInt(p.gasPrice))
+}
+
+// mine includes tx in a block with the given receipt status, and drops the
+// other pooled transactions using its nonce.
+func (p *FakeTxPool) mine(tx *types.Transaction, status uint) *types.Receipt {
+	for hash, pooled := range p.pool {
+		if pooled.Nonce() == tx.Nonce() && hash != tx.Hash() {
+			delete(p.pool, hash)
+		}
+	}
+	if p.receipts == nil {
+		p.receipts = make(map[common.Hash]*types.Receipt)
+	}
+	receipt := types.NewReceipt(nil, status == types.ReceiptStatusFailed, tx.Gas())
+	receipt.TxHash = tx.Hash()
+	receipt.GasUsed = tx.Gas()
+	receipt.Logs = []*types.Log{}
+	p.receipts[tx.Hash()] = receipt
+	return receipt
+}
+
+// FakeMiner is a transaction pool which mines every transaction it receives
+// with the given receipt status.
+type FakeMiner struct {
+	*FakeTxPool
+	status uint
+}
+
+func (m *FakeMiner) SendRawTransaction(raw hexutil.Bytes) (common.Hash, error) {
+	hash, err := m.FakeTxPool.SendRawTransaction(raw)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	m.mine(m.pool[hash], m.status)
+	m.nonce++
+	return hash, nil
+}
+
+// FakeState is a stand-in for the state access of a node.
+type FakeState struct {
+	head       int64
+	callBlocks []string
+	storage    map[common.Hash]common.Hash
+	code       map[common.Address]hexutil.Bytes
+	// results maps hex encoded call data to canned call results, failures
+	// to canned call errors.
+	results  map[string]hexutil.Bytes
+	failures map[string]string
+}
+
+// Call returns the canned result or error for the call data if any,
+// otherwise it echoes the call data back, or reverts if the data is "revert".
+func (s *FakeState) Call(args map[string]interface{}, block string) (hexutil.Bytes, error) {
+	s.callBlocks = append(s.callBlocks, block)
+	if result, ok := s.results[args["data"].(string)]; ok {
+		return result, nil
+	}
+	if failure, ok := s.failures[args["data"].(string)]; ok {
+		return nil, errors.New(failure)
+	}
+	data, _ := hexutil.Decode(args["data"].(string))
+	if string(data) == "revert" {
+		return nil, errors.New("execution reverted: reverted")
+	}
+	return data, nil
+}
+
+func (s *FakeState) BlockNumber() *hexutil.Big {
+	return (*hexutil.Big)(big.NewInt(s.head))
+}
+
+func (s *FakeState) GetCode(account common.Address, block string) hexutil.Bytes {
+	return s.code[account]
+}
+
+func (s *FakeState) GetStorageAt(account common.Address, key common.Hash, block string) hexutil.Bytes {
+	value := s.storage[key]
+	return value.Bytes()
+}
+
+// FakeAccount is a stand-in for an account unlocked in a node.
+type FakeAccount struct {
+	key *ecdsa.PrivateKey
+}
+
+func (a *FakeAccount) Sign(account common.Address, data hexutil.Bytes) (hexutil.Bytes, error) {
+	if crypto.PubkeyToAddress(a.key.PublicKey) != account {
+		return nil, errors.New("unknown account")
+	}
+	return SignHash(TextHash(data), a.key)
+}
+
+// FakeChain is a stand-in for the chain data of a node.
+type FakeChain struct {
+	// headers is the chain, indexed by block number. If blocks is set, it
+	// takes precedence and full blocks are served.
+	headers  []*types.Header
+	blocks   []*types.Block
+	receipts map[common.Hash]*types.Receipt
+	// blockFailures is the number of block requests that fail before
+	// requests succeed again.
+	blockFailures int32
+	// logs are returned by eth_getLogs, which fails if more than maxLogs
+	// match when maxLogs is set.
+	logs    []types.Log
+	maxLogs int
+}
+
+func (c *FakeChain) GetBlockByNumber(number string, full bool) (interface{}, error) {
+	if atomic.AddInt32(&c.blockFailures, -1) >= 0 {
+		return nil, errors.New("temporary failure")
+	}
+	count := len(c.headers)
+	if c.blocks != nil {
+		count = len(c.blocks)
+	}
+	i, err := hexutil.DecodeUint64(number)
+	if number == "latest" {
+		i, err = uint64(count-1), nil
+	}
+	if err != nil || i >= uint64(count) {
+		return nil, nil
+	}
+	if c.blocks != nil {
+		return rpcMarshalBlock(c.blocks[i]), nil
+	}
+	return c.headers[i], nil
+}
+
+func (c *FakeChain) GetBlockByHash(hash common.Hash, full bool) interface{} {
+	for _, block := range c.blocks {
+		if block.Hash() == hash {
+			return rpcMarshalBlock(block)
+		}
+	}
+	return nil
+}
+
+func (c *FakeChain) GetTransactionReceipt(hash common.Hash) *types.Receipt {
+	return c.receipts[hash]
+}
+
+// rpcMarshalBlock encodes a block with full transactions as a node does.
+func rpcMarshalBlock(block *types.Block) map[string]interface{} {
+	fields := make(map[string]interface{})
+	data, _ := json.Marshal(block.Header())
+	json.Unmarshal(data, &fields)
+	fields["hash"] = block.Hash()
+	fields["transactions"] = block.Transactions()
+	fields["uncles"] = []common.Hash{}
+	return fields
+}
+
+func (c *FakeChain) GetLogs(args map[string]interface{}) ([]types.Log, error) {
+	from, _ := hexutil.DecodeUint64(args["fromBlock"].(string))
+	to, _ := hexutil.DecodeUint64(args["toBlock"].(string))
+	logs := []types.Log{}
+	for _, l := range c.logs {
+		if l.BlockNumber >= from && l.BlockNumber <= to {
+			logs = append(logs, l)
+		}
+	}
+	if c.maxLogs > 0 && len(logs) > c.maxLogs {
+		return nil, fmt.Errorf("query returned more than %d results", c.maxLogs)
+	}
+	return logs, nil
+}
@@ -0,0 +1,169 @@
+// Copyright 2017 AMIS Technologies
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// ErrNoCode is returned by contract calls if there is no code at the contract
+// address.
+var ErrNoCode = errors.New("no contract code at given address")
+
+// Contract is a handle to a deployed contract which packs and unpacks calls
+// according to its ABI.
+type Contract struct {
+	client  Client
+	abi     abi.ABI
+	address common.Address
+}
+
+// NewContract creates a handle to the contract at address described by the
+// given ABI JSON.
+func NewContract(client Client, abiJSON string, address common.Address) (*Contract, error) {
+	parsed, err := abi.JSON(strings.NewReader(abiJSON))
+	if err != nil {
+		return nil, err
+	}
+	return NewContractWithABI(client, parsed, address), nil
+}
+
+// NewContractWithABI creates a handle to the contract at address described by
+// an already parsed ABI.
+func NewContractWithABI(client Client, parsed abi.ABI, address common.Address) *Contract {
+	return &Contract{
+		client:  client,
+		abi:     parsed,
+		address: address,
+	}
+}
+
+// Address returns the address of the contract.
+func (c *Contract) Address() common.Address {
+	return c.address
+}
+
+// ABI returns the ABI of the contract.
+func (c *Contract) ABI() abi.ABI {
+	return c.abi
+}
+
+// Pack encodes the call of method with the given arguments.
+func (c *Contract) Pack(method string, args ...interface{}) ([]byte, error) {
+	return c.abi.Pack(method, args...)
+}
+
+// Call calls method at the given block and unpacks its outputs into result,
+// which follows the rules of abi.ABI.Unpack. A nil blockNumber calls at the
+// latest block.
+func (c *Contract) Call(ctx context.Context, blockNumber *big.Int, result interface{}, method string, args ...interface{}) error {
+	output, err := c.call(ctx, blockNumber, method, args...)
+	if err != nil {
+		return err
+	}
+	return c.abi.Unpack(result, method, output)
+}
+
+// CallOutput calls method at the given block and returns its raw output.
+func (c *Contract) CallOutput(ctx context.Context, blockNumber *big.Int, method string, args ...interface{}) ([]byte, error) {
+	return c.call(ctx, blockNumber, method, args...)
+}
+
+// CallValues calls method at the given block and returns its outputs as Go
+// values.
+func (c *Contract) CallValues(ctx context.Context, blockNumber *big.Int, method string, args ...interface{}) ([]interface{}, error) {
+	output, err := c.call(ctx, blockNumber, method, args...)
+	if err != nil {
+		return nil, err
+	}
+	return unpackArguments(c.abi.Methods[method].Outputs, output)
+}
+
+// CallMap calls method at the given block and returns its outputs keyed by
+// name. Unnamed outputs are keyed by their position.
+func (c *Contract) CallMap(ctx context.Context, blockNumber *big.Int, method string, args ...interface{}) (map[string]interface{}, error) {
+	values, err := c.CallValues(ctx, blockNumber, method, args...)
+	if err != nil {
+		return nil, err
+	}
+	return argumentsMap(c.abi.Methods[method].Outputs, values), nil
+}
+
+// NewCall prepares a call of method for a Multicaller, which decodes its
+// outputs.
+func (c *Contract) NewCall(method string, args ...interface{}) (Call, error) {
+	m, ok := c.abi.Methods[method]
+	if !ok {
+		return Call{}, fmt.Errorf("method '%s' not found", method)
+	}
+	input, err := c.abi.Pack(method, args...)
+	if err != nil {
+		return Call{}, err
+	}
+	return Call{
+		Msg:    ethereum.CallMsg{To: &c.address, Data: input},
+		Method: &m,
+	}, nil
+}
+
+// Transact sends a transaction calling method with args through the
+// transactor, with value wei attached. A nil value sends no ether. The
+// options are passed on to the transactor.
+func (c *Contract) Transact(ctx context.Context, transactor *Transactor, value *big.Int, method string, args []interface{}, opts ...TxOption) (*types.Transaction, error) {
+	input, err := c.abi.Pack(method, args...)
+	if err != nil {
+		return nil, err
+	}
+	return transactor.Transact(ctx, ethereum.CallMsg{
+		To:    &c.address,
+		Value: value,
+		Data:  input,
+	}, opts...)
+}
+
+func (c *Contract) call(ctx context.Context, blockNumber *big.Int, method string, args ...interface{}) ([]byte, error) {
+	if _, ok := c.abi.Methods[method]; !ok {
+		return nil, fmt.Errorf("method '%s' not found", method)
+	}
+	input, err := c.abi.Pack(method, args...)
+	if err != nil {
+		return nil, err
+	}
+	output, err := c.client.CallContract(ctx, ethereum.CallMsg{To: &c.address, Data: input}, blockNumber)
+	if err != nil {
+		return nil, err
+	}
+	if len(output) == 0 && len(c.abi.Methods[method].Outputs) > 0 {
+		code, err := c.client.CodeAt(ctx, c.address, blockNumber)
+		if err != nil {
+			return nil, err
+		}
+		if len(code) == 0 {
+			return nil, ErrNoCode
+		}
+	}
+	return output, nil
+}
@@ -0,0 +1,142 @@
+// Copyright 2017 AMIS Technologies
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package client
+
+import (
+	"bytes"
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+const testContractABI = `[
+	{"type":"function","name":"get","constant":true,"inputs":[{"name":"key","type":"uint256"}],"outputs":[{"name":"value","type":"uint256"},{"name":"owner","type":"address"}]},
+	{"type":"function","name":"pair","constant":true,"inputs":[],"outputs":[{"name":"","type":"uint256"},{"name":"","type":"bool"}]},
+	{"type":"function","name":"set","constant":false,"inputs":[{"name":"key","type":"uint256"},{"name":"value","type":"uint256"}],"outputs":[]}
+]`
+
+func TestContractCall(t *testing.T) {
+	address := common.HexToAddress("0x00000000000000000000000000000000000000cc")
+	owner := common.HexToAddress("0x00000000000000000000000000000000000000aa")
+	state := &FakeState{results: make(map[string]hexutil.Bytes), code: make(map[common.Address]hexutil.Bytes)}
+	c := newTestNodeClient(t, state)
+	defer c.Close()
+	ctx := context.Background()
+
+	contract, err := NewContract(c, testContractABI, address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := func(method string, args []interface{}, output []byte) {
+		input, err := contract.Pack(method, args...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		state.results[hexutil.Encode(input)] = output
+	}
+	word := func(v int64) []byte { return common.LeftPadBytes(big.NewInt(v).Bytes(), 32) }
+	result("get", []interface{}{big.NewInt(7)}, append(word(42), common.LeftPadBytes(owner.Bytes(), 32)...))
+	result("get", []interface{}{big.NewInt(8)}, []byte{})
+	result("pair", nil, append(word(5), word(1)...))
+
+	var get struct {
+		Value *big.Int
+		Owner common.Address
+	}
+	if err := contract.Call(ctx, nil, &get, "get", big.NewInt(7)); err != nil {
+		t.Fatal(err)
+	}
+	if get.Value.Int64() != 42 || get.Owner != owner {
+		t.Errorf("call mismatch: have %v %x, want 42 %x", get.Value, get.Owner, owner)
+	}
+
+	values, err := contract.CallValues(ctx, big.NewInt(3), "get", big.NewInt(7))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(values) != 2 || values[0].(*big.Int).Int64() != 42 || values[1].(common.Address) != owner {
+		t.Errorf("values mismatch: have %v", values)
+	}
+	if block := state.callBlocks[len(state.callBlocks)-1]; block != "0x3" {
+		t.Errorf("block mismatch: have %s, want 0x3", block)
+	}
+
+	m, err := contract.CallMap(ctx, nil, "pair")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m) != 2 || m["0"].(*big.Int).Int64() != 5 || m["1"] != true {
+		t.Errorf("map mismatch: have %v", m)
+	}
+	m, err = contract.CallMap(ctx, nil, "get", big.NewInt(7))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m["value"].(*big.Int).Int64() != 42 || m["owner"] != owner {
+		t.Errorf("map mismatch: have %v", m)
+	}
+
+	// An empty result is an error only if there is no code.
+	if err := contract.Call(ctx, nil, &get, "get", big.NewInt(8)); err != ErrNoCode {
+		t.Errorf("error mismatch: have %v, want %v", err, ErrNoCode)
+	}
+	state.code[address] = hexutil.Bytes{0x60}
+	if err := contract.Call(ctx, nil, &get, "get", big.NewInt(8)); err == nil || err == ErrNoCode {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if _, err := contract.CallValues(ctx, nil, "missing"); err == nil {
+		t.Error("expected an error for an unknown method")
+	}
+}
+
+func TestContractTransact(t *testing.T) {
+	pool := &FakeTxPool{}
+	c := newTestNodeClient(t, pool, &FakeExecution{estimate: 30000})
+	defer c.Close()
+
+	key, _ := crypto.GenerateKey()
+	transactor := NewTransactor(c, NewKeySigner(key), big.NewInt(2017))
+	address := common.HexToAddress("0x00000000000000000000000000000000000000cc")
+	contract, err := NewContract(c, testContractABI, address)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	args := []interface{}{big.NewInt(1), big.NewInt(2)}
+	tx, err := contract.Transact(context.Background(), transactor, big.NewInt(10), "set", args,
+		WithNonce(9), WithGasPriceOracle(NewFixedGasPriceOracle(big.NewInt(3))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	input, _ := contract.Pack("set", args...)
+	if *tx.To() != address || tx.Value().Int64() != 10 || !bytes.Equal(tx.Data(), input) {
+		t.Errorf("transaction mismatch: to %x, value %v, data %x", tx.To(), tx.Value(), tx.Data())
+	}
+	if tx.Nonce() != 9 || tx.GasPrice().Int64() != 3 || tx.Gas().Int64() != 30000 {
+		t.Errorf("options not applied: nonce %d, gas price %v, gas %v", tx.Nonce(), tx.GasPrice(), tx.Gas())
+	}
+	if len(pool.sent) != 1 || pool.sent[0] != tx.Hash() {
+		t.Errorf("broadcast mismatch: have %x, want %x", pool.sent, tx.Hash())
+	}
+	if _, err := contract.Transact(context.Background(), transactor, nil, "set", nil); err == nil {
+		t.Error("expected an error for missing arguments")
+	}
+}
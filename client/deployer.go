@@ -0,0 +1,148 @@
+// Copyright 2017 AMIS Technologies
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package client
+
+import (
+	"bytes"
+	"context"
+	"errors"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+var (
+	// ErrDeployFailed is returned if a contract creation transaction failed.
+	ErrDeployFailed = errors.New("contract deployment failed")
+	// ErrNoCodeAfterDeploy is returned if a contract creation left no code
+	// behind.
+	ErrNoCodeAfterDeploy = errors.New("no contract code after deployment")
+	// ErrCodeMismatch is returned if the deployed code differs from the
+	// expected runtime bytecode.
+	ErrCodeMismatch = errors.New("deployed code does not match runtime bytecode")
+	// ErrAddressMismatch is returned if a contract was not deployed at the
+	// predicted address.
+	ErrAddressMismatch = errors.New("contract deployed at unexpected address")
+)
+
+// Deployer deploys contracts through a transactor and verifies the result.
+type Deployer struct {
+	transactor *Transactor
+}
+
+// NewDeployer creates a deployer which sends contract creations through
+// transactor and verifies them with the transactor's client.
+func NewDeployer(transactor *Transactor) *Deployer {
+	return &Deployer{
+		transactor: transactor,
+	}
+}
+
+// PredictAddress returns the address of the next contract created by the
+// deployer's account.
+func (d *Deployer) PredictAddress(ctx context.Context) (common.Address, error) {
+	nonce, err := d.transactor.client.PendingNonceAt(ctx, d.transactor.Address())
+	if err != nil {
+		return common.Address{}, err
+	}
+	return crypto.CreateAddress(d.transactor.Address(), nonce), nil
+}
+
+// Deploy creates a contract from bytecode and the packed constructor args,
+// waits until it has been mined and checks the code at the new address. If
+// runtime is not empty, the deployed code must match it, ignoring the solc
+// metadata hash. It returns the contract address, the creation receipt and a
+// handle to the new contract.
+func (d *Deployer) Deploy(ctx context.Context, parsed abi.ABI, bytecode []byte, runtime []byte, args ...interface{}) (common.Address, *types.Receipt, *Contract, error) {
+	address, tx, err := d.send(ctx, parsed, bytecode, args...)
+	if err != nil {
+		return common.Address{}, nil, nil, err
+	}
+	receipt, contract, err := d.wait(ctx, parsed, address, tx.Hash(), runtime)
+	return address, receipt, contract, err
+}
+
+// send sends the creation of a contract and returns its address together
+// with the creation transaction.
+func (d *Deployer) send(ctx context.Context, parsed abi.ABI, bytecode []byte, args ...interface{}) (common.Address, *types.Transaction, error) {
+	input, err := parsed.Pack("", args...)
+	if err != nil {
+		return common.Address{}, nil, err
+	}
+	input = append(common.CopyBytes(bytecode), input...)
+
+	from := d.transactor.Address()
+	nonce, err := d.transactor.client.PendingNonceAt(ctx, from)
+	if err != nil {
+		return common.Address{}, nil, err
+	}
+	address := crypto.CreateAddress(from, nonce)
+
+	tx, err := d.transactor.Transact(ctx, ethereum.CallMsg{Data: input}, WithNonce(nonce))
+	if err != nil {
+		return common.Address{}, nil, err
+	}
+	log.Info("Contract creation sent", "hash", tx.Hash().Hex(), "address", address.Hex())
+	return address, tx, nil
+}
+
+// wait waits until the creation transaction hash has been mined and verifies
+// the code deployed at address.
+func (d *Deployer) wait(ctx context.Context, parsed abi.ABI, address common.Address, hash common.Hash, runtime []byte) (*types.Receipt, *Contract, error) {
+	receipt, err := WaitMined(ctx, d.transactor.client, hash)
+	if err != nil {
+		return nil, nil, err
+	}
+	if receiptFailed(receipt) {
+		return receipt, nil, ErrDeployFailed
+	}
+	if receipt.ContractAddress != (common.Address{}) && receipt.ContractAddress != address {
+		return receipt, nil, ErrAddressMismatch
+	}
+
+	code, err := d.transactor.client.CodeAt(ctx, address, nil)
+	if err != nil {
+		return receipt, nil, err
+	}
+	if len(code) == 0 {
+		return receipt, nil, ErrNoCodeAfterDeploy
+	}
+	if len(runtime) > 0 && !bytes.Equal(StripMetadata(maskCallProtection(code, runtime)), StripMetadata(runtime)) {
+		return receipt, nil, ErrCodeMismatch
+	}
+	return receipt, NewContractWithABI(d.transactor.client, parsed, address), nil
+}
+
+// maskCallProtection zeroes the library address in code if runtime is the
+// runtime bytecode of a library. Libraries start with a PUSH20 of their own
+// address, which solc leaves as zeros in the runtime bytecode and fills in
+// during the deployment.
+func maskCallProtection(code, runtime []byte) []byte {
+	const size = 1 + common.AddressLength
+	if len(runtime) < size || len(code) < size || runtime[0] != 0x73 || code[0] != 0x73 {
+		return code
+	}
+	if !bytes.Equal(runtime[1:size], make([]byte, common.AddressLength)) {
+		return code
+	}
+	masked := common.CopyBytes(code)
+	copy(masked[1:size], make([]byte, common.AddressLength))
+	return masked
+}
@@ -0,0 +1,89 @@
+// Copyright 2017 AMIS Technologies
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package client
+
+import (
+	"bytes"
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func TestDeployer(t *testing.T) {
+	parsed := MustParseABI(`[{"type":"constructor","inputs":[{"name":"x","type":"uint256"}]}]`)
+	bytecode := []byte{0x60, 0x80, 0x60, 0x40}
+	runtime := []byte{0x60, 0x01, 0x00}
+
+	key, _ := crypto.GenerateKey()
+	from := crypto.PubkeyToAddress(key.PublicKey)
+	args, _ := parsed.Pack("", big.NewInt(5))
+
+	tests := []struct {
+		status uint
+		code   []byte
+		want   error
+	}{
+		{status: types.ReceiptStatusSuccessful, code: append(runtime, bzzr0Metadata...)},
+		{status: types.ReceiptStatusSuccessful, code: []byte{0x60, 0x02, 0x00}, want: ErrCodeMismatch},
+		{status: types.ReceiptStatusSuccessful, want: ErrNoCodeAfterDeploy},
+		{status: types.ReceiptStatusFailed, want: ErrDeployFailed},
+	}
+	for i, test := range tests {
+		pool := &FakeTxPool{nonce: 4, gasPrice: 1}
+		state := &FakeState{code: map[common.Address]hexutil.Bytes{}}
+		c := newTestNodeClient(t, &FakeMiner{FakeTxPool: pool, status: test.status}, state, &FakeExecution{estimate: 50000})
+		deployer := NewDeployer(NewTransactor(c, NewKeySigner(key), big.NewInt(2017)))
+
+		want := crypto.CreateAddress(from, 4)
+		predicted, err := deployer.PredictAddress(context.Background())
+		if err != nil {
+			t.Fatalf("test %d: %v", i, err)
+		}
+		if predicted != want {
+			t.Errorf("test %d: predicted address mismatch: have %x, want %x", i, predicted, want)
+		}
+		if test.code != nil {
+			state.code[want] = test.code
+		}
+
+		address, receipt, contract, err := deployer.Deploy(context.Background(), parsed, bytecode, runtime, big.NewInt(5))
+		c.Close()
+		if err != test.want {
+			t.Errorf("test %d: error mismatch: have %v, want %v", i, err, test.want)
+		}
+		if address != want {
+			t.Errorf("test %d: address mismatch: have %x, want %x", i, address, want)
+		}
+		if len(pool.sent) != 1 || receipt == nil || receipt.TxHash != pool.sent[0] {
+			t.Errorf("test %d: receipt mismatch: have %v, want receipt of %x", i, receipt, pool.sent)
+			continue
+		}
+		if tx := pool.pool[pool.sent[0]]; tx.To() != nil || tx.Nonce() != 4 || !bytes.Equal(tx.Data(), append(bytecode, args...)) {
+			t.Errorf("test %d: creation mismatch: to %v, nonce %d, data %x", i, tx.To(), tx.Nonce(), tx.Data())
+		}
+		if (contract != nil) != (test.want == nil) {
+			t.Errorf("test %d: contract mismatch: have %v", i, contract)
+		} else if contract != nil && contract.Address() != want {
+			t.Errorf("test %d: contract address mismatch: have %x, want %x", i, contract.Address(), want)
+		}
+	}
+}
@@ -0,0 +1,304 @@
+// Copyright 2017 AMIS Technologies
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// BlockBundle is a block together with the receipts of its transactions.
+type BlockBundle struct {
+	Block *types.Block
+	// Receipts is nil if receipts were not requested.
+	Receipts []*types.Receipt
+	// PrivateReceipts is set if the receipts could not be checked against the
+	// receipt root, as the block holds Quorum private transactions whose
+	// receipts returned by the node are not the public ones in the root.
+	PrivateReceipts bool
+}
+
+// DownloadProgress reports the progress of a download.
+type DownloadProgress struct {
+	From, To uint64
+	// Current is the last block delivered.
+	Current uint64
+	Elapsed time.Duration
+}
+
+// DownloadCheckpoint persists the position of a download so that it can be
+// resumed after a restart.
+type DownloadCheckpoint interface {
+	// Load returns the saved block number, if any.
+	Load() (number uint64, ok bool, err error)
+	// Save records that all blocks up to number have been handled.
+	Save(number uint64) error
+}
+
+type fileCheckpoint struct {
+	path string
+}
+
+// NewFileCheckpoint creates a checkpoint saved as JSON in the file at path.
+func NewFileCheckpoint(path string) DownloadCheckpoint {
+	return &fileCheckpoint{path: path}
+}
+
+func (c *fileCheckpoint) Load() (uint64, bool, error) {
+	data, err := ioutil.ReadFile(c.path)
+	if os.IsNotExist(err) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	var number hexutil.Uint64
+	if err := json.Unmarshal(data, &number); err != nil {
+		return 0, false, err
+	}
+	return uint64(number), true, nil
+}
+
+func (c *fileCheckpoint) Save(number uint64) error {
+	data, err := json.Marshal(hexutil.Uint64(number))
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(c.path, data)
+}
+
+// DownloaderConfig contains the settings of a block downloader.
+type DownloaderConfig struct {
+	// Concurrency is the number of batches fetched in parallel.
+	Concurrency int
+	// BatchSize is the number of blocks fetched in one batch request.
+	BatchSize int
+	// Receipts enables fetching the receipts of every block.
+	Receipts bool
+	// MaxRetries is the number of times a failed batch is retried. Zero
+	// disables retries.
+	MaxRetries int
+	// RetryDelay is the time to wait before retrying a failed batch.
+	RetryDelay time.Duration
+	// Lookahead is the maximum number of blocks fetched ahead of the block
+	// the consumer waits for.
+	Lookahead int
+	// Progress is called after every delivered batch, if not nil.
+	Progress func(DownloadProgress)
+	// Checkpoint persists the download position, if not nil.
+	Checkpoint DownloadCheckpoint
+	// CheckpointInterval is the number of blocks between two saves.
+	CheckpointInterval uint64
+}
+
+// DefaultDownloaderConfig contains the default downloader settings.
+var DefaultDownloaderConfig = DownloaderConfig{
+	Concurrency:        4,
+	BatchSize:          20,
+	MaxRetries:         5,
+	RetryDelay:         time.Second,
+	Lookahead:          1000,
+	CheckpointInterval: 100,
+}
+
+// Downloader fetches block ranges in parallel batches and delivers the
+// blocks in strict order.
+type Downloader struct {
+	client Client
+	config DownloaderConfig
+}
+
+// NewDownloader creates a downloader on top of client. Unset settings take
+// their default values, except MaxRetries as zero retries are valid; start
+// from DefaultDownloaderConfig to retry failed batches.
+func NewDownloader(client Client, config DownloaderConfig) *Downloader {
+	if config.Concurrency <= 0 {
+		config.Concurrency = DefaultDownloaderConfig.Concurrency
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = DefaultDownloaderConfig.BatchSize
+	}
+	if config.RetryDelay == 0 {
+		config.RetryDelay = DefaultDownloaderConfig.RetryDelay
+	}
+	if config.Lookahead < config.BatchSize*config.Concurrency {
+		config.Lookahead = config.BatchSize * config.Concurrency
+	}
+	if config.CheckpointInterval == 0 {
+		config.CheckpointInterval = DefaultDownloaderConfig.CheckpointInterval
+	}
+	return &Downloader{
+		client: client,
+		config: config,
+	}
+}
+
+type blockBatch struct {
+	from, to uint64
+	bundles  []*BlockBundle
+	err      error
+}
+
+// Download fetches the blocks from and to inclusive and sends them on ch in
+// order, blocking while the consumer is behind. It returns when all blocks
+// are delivered, the context is cancelled or a batch fails after all retries.
+//
+// If a checkpoint is configured, the download resumes after the saved block.
+// When a block is delivered, the block before it is saved as handled, which
+// holds for consumers handling blocks one at a time from an unbuffered
+// channel. Saves happen every CheckpointInterval blocks and once all blocks
+// are delivered, so a resumed download may deliver up to CheckpointInterval
+// blocks again.
+func (d *Downloader) Download(ctx context.Context, from, to uint64, ch chan<- *BlockBundle) error {
+	var saved uint64
+	if d.config.Checkpoint != nil {
+		number, ok, err := d.config.Checkpoint.Load()
+		if err != nil {
+			return err
+		}
+		if ok && number >= from {
+			log.Info("Resuming download", "checkpoint", number)
+			from, saved = number+1, number
+		}
+	}
+	if from > to {
+		return nil
+	}
+
+	var (
+		start    = time.Now()
+		results  = make(chan blockBatch)
+		cursor   = from
+		next     = from
+		done     = make(map[uint64]blockBatch)
+		inflight int
+	)
+	defer func() {
+		for ; inflight > 0; inflight-- {
+			<-results
+		}
+	}()
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	for {
+		for inflight < d.config.Concurrency && cursor <= to && cursor-next < uint64(d.config.Lookahead) {
+			batch := blockBatch{from: cursor, to: to}
+			if to-cursor >= uint64(d.config.BatchSize) {
+				batch.to = cursor + uint64(d.config.BatchSize) - 1
+			}
+			cursor = batch.to + 1
+			inflight++
+			go d.fetch(ctx, batch, results)
+		}
+		if inflight == 0 {
+			if d.config.Checkpoint != nil && saved < to {
+				return d.config.Checkpoint.Save(to)
+			}
+			return nil
+		}
+
+		batch := <-results
+		inflight--
+		if batch.err != nil {
+			return batch.err
+		}
+		done[batch.from] = batch
+		for {
+			batch, ok := done[next]
+			if !ok {
+				break
+			}
+			delete(done, next)
+			for _, bundle := range batch.bundles {
+				select {
+				case ch <- bundle:
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+				number := bundle.Block.NumberU64()
+				if d.config.Checkpoint != nil && number > from && number-1 >= saved+d.config.CheckpointInterval {
+					if err := d.config.Checkpoint.Save(number - 1); err != nil {
+						return err
+					}
+					saved = number - 1
+				}
+			}
+			next = batch.to + 1
+			if d.config.Progress != nil {
+				d.config.Progress(DownloadProgress{
+					From:    from,
+					To:      to,
+					Current: batch.to,
+					Elapsed: time.Since(start),
+				})
+			}
+		}
+	}
+}
+
+// fetch fetches a batch of blocks, retrying on failure, and sends the result
+// to results.
+func (d *Downloader) fetch(ctx context.Context, batch blockBatch, results chan<- blockBatch) {
+	ids := make([]interface{}, 0, batch.to-batch.from+1)
+	for n := batch.from; n <= batch.to; n++ {
+		ids = append(ids, hexutil.EncodeUint64(n))
+	}
+	for attempt := 0; ; attempt++ {
+		batch.bundles, batch.err = d.fetchBundles(ctx, ids)
+		if batch.err == nil || attempt >= d.config.MaxRetries || ctx.Err() != nil {
+			break
+		}
+		log.Warn("Failed to fetch blocks, retrying", "from", batch.from, "to", batch.to, "attempt", attempt+1, "err", batch.err)
+		select {
+		case <-time.After(d.config.RetryDelay):
+		case <-ctx.Done():
+		}
+	}
+	results <- batch
+}
+
+func (d *Downloader) fetchBundles(ctx context.Context, ids []interface{}) ([]*BlockBundle, error) {
+	blocks, err := batchBlocks(ctx, d.client, "eth_getBlockByNumber", ids)
+	if err != nil {
+		return nil, err
+	}
+	bundles := make([]*BlockBundle, len(blocks))
+	for i, block := range blocks {
+		bundles[i] = &BlockBundle{Block: block}
+	}
+	if !d.config.Receipts {
+		return bundles, nil
+	}
+	receipts, err := batchReceipts(ctx, d.client, blocks)
+	if err != nil {
+		return nil, err
+	}
+	for i := range bundles {
+		if err := VerifyReceipts(blocks[i], receipts[i]); err != nil {
+			return nil, err
+		}
+		bundles[i].Receipts = receipts[i]
+	}
+	return bundles, nil
+}
@@ -0,0 +1,188 @@
+// Copyright 2017 AMIS Technologies
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package client
+
+import (
+	"context"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// newTestBlocks fills chain with n blocks, where block i contains
+// i%3 signed transactions, and the receipts of those transactions.
+func newTestBlocks(t *testing.T, chain *FakeChain, n int) {
+	key, _ := crypto.GenerateKey()
+	signer := types.NewEIP155Signer(big.NewInt(2017))
+	to := common.HexToAddress("0x00000000000000000000000000000000000000aa")
+
+	chain.blocks = make([]*types.Block, n)
+	chain.receipts = make(map[common.Hash]*types.Receipt)
+	var nonce uint64
+	for i := range chain.blocks {
+		var (
+			txs      []*types.Transaction
+			receipts []*types.Receipt
+			gasUsed  = new(big.Int)
+		)
+		for j := 0; j < i%3; j++ {
+			tx, err := types.SignTx(types.NewTransaction(nonce, to, common.Big1, big.NewInt(21000), common.Big1, nil), signer, key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			nonce++
+			gasUsed.Add(gasUsed, big.NewInt(21000))
+			receipt := types.NewReceipt(nil, false, new(big.Int).Set(gasUsed))
+			receipt.TxHash = tx.Hash()
+			receipt.GasUsed = big.NewInt(21000)
+			receipt.Logs = []*types.Log{}
+			receipt.Bloom = types.CreateBloom(types.Receipts{receipt})
+			txs = append(txs, tx)
+			receipts = append(receipts, receipt)
+			chain.receipts[tx.Hash()] = receipt
+		}
+		header := &types.Header{
+			Number:     big.NewInt(int64(i)),
+			Time:       big.NewInt(1500000000 + int64(i)),
+			Difficulty: common.Big1,
+			GasLimit:   big.NewInt(4700000),
+			GasUsed:    gasUsed,
+		}
+		if i > 0 {
+			header.ParentHash = chain.blocks[i-1].Hash()
+		}
+		chain.blocks[i] = types.NewBlock(header, txs, nil, receipts)
+	}
+}
+
+func TestDownloader(t *testing.T) {
+	chain := &FakeChain{blockFailures: 2}
+	newTestBlocks(t, chain, 300)
+	client := newTestNodeClient(t, chain)
+
+	var progress DownloadProgress
+	downloader := NewDownloader(client, DownloaderConfig{
+		Concurrency: 3,
+		BatchSize:   7,
+		Receipts:    true,
+		MaxRetries:  2,
+		RetryDelay:  time.Millisecond,
+		Progress:    func(p DownloadProgress) { progress = p },
+	})
+
+	ch := make(chan *BlockBundle)
+	errc := make(chan error, 1)
+	go func() { errc <- downloader.Download(context.Background(), 10, 250, ch) }()
+
+	for want := uint64(10); want <= 250; want++ {
+		var bundle *BlockBundle
+		select {
+		case bundle = <-ch:
+		case err := <-errc:
+			t.Fatalf("download failed: %v", err)
+		}
+		if bundle.Block.NumberU64() != want {
+			t.Fatalf("out of order block: have %d, want %d", bundle.Block.NumberU64(), want)
+		}
+		if bundle.Block.Hash() != chain.blocks[want].Hash() {
+			t.Fatalf("block %d: hash mismatch", want)
+		}
+		if len(bundle.Receipts) != len(bundle.Block.Transactions()) {
+			t.Fatalf("block %d: have %d receipts, want %d", want, len(bundle.Receipts), len(bundle.Block.Transactions()))
+		}
+		for i, receipt := range bundle.Receipts {
+			if receipt.TxHash != bundle.Block.Transactions()[i].Hash() {
+				t.Fatalf("block %d: receipt %d mismatch", want, i)
+			}
+		}
+	}
+	if err := <-errc; err != nil {
+		t.Fatal(err)
+	}
+	if progress.Current != 250 || progress.From != 10 || progress.To != 250 {
+		t.Errorf("unexpected final progress: %+v", progress)
+	}
+}
+
+func TestDownloaderCheckpoint(t *testing.T) {
+	dir, err := ioutil.TempDir("", "downloader")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	chain := &FakeChain{}
+	newTestBlocks(t, chain, 200)
+	downloader := NewDownloader(newTestNodeClient(t, chain), DownloaderConfig{
+		Checkpoint:         NewFileCheckpoint(filepath.Join(dir, "checkpoint")),
+		CheckpointInterval: 25,
+	})
+
+	// Stop after handling block 120.
+	ctx, cancel := context.WithCancel(context.Background())
+	ch := make(chan *BlockBundle)
+	errc := make(chan error, 1)
+	go func() { errc <- downloader.Download(ctx, 0, 199, ch) }()
+	for bundle := range ch {
+		if bundle.Block.NumberU64() == 120 {
+			break
+		}
+	}
+	cancel()
+	if err := <-errc; err != context.Canceled {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ch = make(chan *BlockBundle)
+	go func() { errc <- downloader.Download(context.Background(), 0, 199, ch) }()
+	first := (<-ch).Block.NumberU64()
+	if first > 120 || first < 95 {
+		t.Errorf("unexpected resume block: %d", first)
+	}
+	for number := first + 1; number <= 199; number++ {
+		if bundle := <-ch; bundle.Block.NumberU64() != number {
+			t.Fatalf("out of order block: have %d, want %d", bundle.Block.NumberU64(), number)
+		}
+	}
+	if err := <-errc; err != nil {
+		t.Fatal(err)
+	}
+
+	// A finished download saves its last block.
+	if number, ok, err := downloader.config.Checkpoint.Load(); err != nil || !ok || number != 199 {
+		t.Errorf("checkpoint mismatch: have %d, %v, %v, want 199", number, ok, err)
+	}
+	if err := downloader.Download(context.Background(), 0, 199, ch); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDownloaderNoRetries(t *testing.T) {
+	chain := &FakeChain{blockFailures: 1}
+	newTestBlocks(t, chain, 10)
+	downloader := NewDownloader(newTestNodeClient(t, chain), DownloaderConfig{MaxRetries: 0})
+	if err := downloader.Download(context.Background(), 0, 9, make(chan *BlockBundle, 10)); err == nil {
+		t.Error("expected an error without retries")
+	}
+}
@@ -0,0 +1,218 @@
+// Copyright 2017 AMIS Technologies
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package client
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+var (
+	// ErrENSNotFound is returned if a name has no resolver or no record.
+	ErrENSNotFound = errors.New("ens name not found")
+	// ErrENSReverseMismatch is returned by reverse resolution if the name does
+	// not resolve back to the address.
+	ErrENSReverseMismatch = errors.New("ens reverse record does not resolve to address")
+)
+
+// MainnetENSRegistry is the address of the ENS registry on the main network,
+// to be passed to NewENS. The vendored contracts/ens.MainNetAddress is the
+// original registry, which was replaced by this one in 2020.
+var MainnetENSRegistry = common.HexToAddress("0x00000000000C2E074eC69A0dFb2997BA6C7d2e1e")
+
+// ensRegistryABI covers the registry methods used for resolution. It is a
+// subset of ENSABI in the vendored contracts/ens/contract package, which
+// cannot be imported as the dependencies of its bindings are not vendored.
+const ensRegistryABI = `[{"constant":true,"inputs":[{"name":"node","type":"bytes32"}],"name":"resolver","outputs":[{"name":"","type":"address"}],"type":"function"},{"constant":true,"inputs":[{"name":"node","type":"bytes32"}],"name":"owner","outputs":[{"name":"","type":"address"}],"type":"function"}]`
+
+// ensResolverABI covers the resolver profiles used for resolution: addr and
+// content as in ResolverABI of the vendored contracts/ens/contract package,
+// plus name (EIP-181 reverse records) and contenthash (EIP-1577).
+const ensResolverABI = `[{"constant":true,"inputs":[{"name":"node","type":"bytes32"}],"name":"addr","outputs":[{"name":"","type":"address"}],"type":"function"},{"constant":true,"inputs":[{"name":"node","type":"bytes32"}],"name":"name","outputs":[{"name":"","type":"string"}],"type":"function"},{"constant":true,"inputs":[{"name":"node","type":"bytes32"}],"name":"content","outputs":[{"name":"","type":"bytes32"}],"type":"function"},{"constant":true,"inputs":[{"name":"node","type":"bytes32"}],"name":"contenthash","outputs":[{"name":"","type":"bytes"}],"type":"function"}]`
+
+var (
+	ensRegistryParsed = MustParseABI(ensRegistryABI)
+	ensResolverParsed = MustParseABI(ensResolverABI)
+)
+
+// NameHash returns the EIP-137 namehash of an ENS name. Names are lowercased
+// but otherwise not normalized.
+func NameHash(name string) common.Hash {
+	var node common.Hash
+	if name == "" {
+		return node
+	}
+	labels := strings.Split(strings.ToLower(name), ".")
+	for i := len(labels) - 1; i >= 0; i-- {
+		label := crypto.Keccak256Hash([]byte(labels[i]))
+		node = crypto.Keccak256Hash(node.Bytes(), label.Bytes())
+	}
+	return node
+}
+
+// ENS resolves ENS names through the registry at a configurable address, so
+// it works on private chains with their own deployment. There is no default
+// registry; pass MainnetENSRegistry for the main network.
+type ENS struct {
+	client   Client
+	registry *Contract
+}
+
+// NewENS creates a resolver using the ENS registry at registry.
+func NewENS(client Client, registry common.Address) *ENS {
+	return &ENS{
+		client:   client,
+		registry: NewContractWithABI(client, ensRegistryParsed, registry),
+	}
+}
+
+// Resolver returns the resolver contract of name.
+func (e *ENS) Resolver(ctx context.Context, name string) (*Contract, error) {
+	return e.resolver(ctx, NameHash(name))
+}
+
+func (e *ENS) resolver(ctx context.Context, node common.Hash) (*Contract, error) {
+	var addr common.Address
+	if err := e.registry.Call(ctx, nil, &addr, "resolver", node); err != nil {
+		return nil, err
+	}
+	if addr == (common.Address{}) {
+		return nil, ErrENSNotFound
+	}
+	return NewContractWithABI(e.client, ensResolverParsed, addr), nil
+}
+
+// Resolve returns the address name resolves to.
+func (e *ENS) Resolve(ctx context.Context, name string) (common.Address, error) {
+	node := NameHash(name)
+	resolver, err := e.resolver(ctx, node)
+	if err != nil {
+		return common.Address{}, err
+	}
+	var addr common.Address
+	if err := resolver.Call(ctx, nil, &addr, "addr", node); err != nil {
+		return common.Address{}, err
+	}
+	if addr == (common.Address{}) {
+		return common.Address{}, ErrENSNotFound
+	}
+	return addr, nil
+}
+
+// ReverseResolve returns the name of address from its reverse record. The
+// name is checked to resolve back to address, as anyone can claim any name
+// in their reverse record.
+func (e *ENS) ReverseResolve(ctx context.Context, address common.Address) (string, error) {
+	node := NameHash(strings.ToLower(address.Hex()[2:]) + ".addr.reverse")
+	resolver, err := e.resolver(ctx, node)
+	if err != nil {
+		return "", err
+	}
+	var name string
+	if err := resolver.Call(ctx, nil, &name, "name", node); err != nil {
+		return "", err
+	}
+	if name == "" {
+		return "", ErrENSNotFound
+	}
+	forward, err := e.Resolve(ctx, name)
+	if err == ErrENSNotFound || (err == nil && forward != address) {
+		return "", ErrENSReverseMismatch
+	}
+	if err != nil {
+		return "", err
+	}
+	return name, nil
+}
+
+// ContentHash returns the content hash of name. The EIP-1577 contenthash
+// record is preferred, falling back to the legacy content record for
+// resolvers which do not implement contenthash.
+func (e *ENS) ContentHash(ctx context.Context, name string) ([]byte, error) {
+	node := NameHash(name)
+	resolver, err := e.resolver(ctx, node)
+	if err != nil {
+		return nil, err
+	}
+	// Resolvers without the contenthash profile revert or return nothing.
+	output, err := resolver.CallOutput(ctx, nil, "contenthash", node)
+	var revert *RevertError
+	if err != nil && !errors.As(err, &revert) {
+		return nil, err
+	}
+	if err == nil && len(output) > 0 {
+		var hash []byte
+		if err := ensResolverParsed.Unpack(&hash, "contenthash", output); err != nil {
+			return nil, err
+		}
+		if len(hash) > 0 {
+			return hash, nil
+		}
+	}
+	var legacy [32]byte
+	if err := resolver.Call(ctx, nil, &legacy, "content", node); err != nil {
+		return nil, err
+	}
+	if legacy == ([32]byte{}) {
+		return nil, ErrENSNotFound
+	}
+	return legacy[:], nil
+}
+
+// Address returns the address for a hex encoded address or an ENS name.
+func (e *ENS) Address(ctx context.Context, nameOrAddress string) (common.Address, error) {
+	if common.IsHexAddress(nameOrAddress) {
+		return common.HexToAddress(nameOrAddress), nil
+	}
+	return e.Resolve(ctx, nameOrAddress)
+}
+
+// The methods below are the account state reads of Client for an address or
+// an ENS name. Other methods take addresses only; resolve names with Address
+// first.
+
+// BalanceAt is Client.BalanceAt for an address or an ENS name.
+func (e *ENS) BalanceAt(ctx context.Context, nameOrAddress string, blockNumber *big.Int) (*big.Int, error) {
+	addr, err := e.Address(ctx, nameOrAddress)
+	if err != nil {
+		return nil, err
+	}
+	return e.client.BalanceAt(ctx, addr, blockNumber)
+}
+
+// NonceAt is Client.NonceAt for an address or an ENS name.
+func (e *ENS) NonceAt(ctx context.Context, nameOrAddress string, blockNumber *big.Int) (uint64, error) {
+	addr, err := e.Address(ctx, nameOrAddress)
+	if err != nil {
+		return 0, err
+	}
+	return e.client.NonceAt(ctx, addr, blockNumber)
+}
+
+// CodeAt is Client.CodeAt for an address or an ENS name.
+func (e *ENS) CodeAt(ctx context.Context, nameOrAddress string, blockNumber *big.Int) ([]byte, error) {
+	addr, err := e.Address(ctx, nameOrAddress)
+	if err != nil {
+		return nil, err
+	}
+	return e.client.CodeAt(ctx, addr, blockNumber)
+}
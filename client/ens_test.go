@@ -0,0 +1,128 @@
+// Copyright 2017 AMIS Technologies
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package client
+
+import (
+	"bytes"
+	"context"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	ethrpc "github.com/ethereum/go-ethereum/rpc"
+)
+
+func TestNameHash(t *testing.T) {
+	// Test vectors from EIP-137.
+	tests := []struct {
+		name string
+		hash string
+	}{
+		{"", "0x0000000000000000000000000000000000000000000000000000000000000000"},
+		{"eth", "0x93cdeb708b7545dc668eb9280176169d1c33cfd8ed6f04690a0bcc88a93fc4ae"},
+		{"foo.eth", "0xde9b09fd7c5f901e23a3f19fecc54828e9c848539801e86591bd9801b019f84f"},
+		{"Foo.ETH", "0xde9b09fd7c5f901e23a3f19fecc54828e9c848539801e86591bd9801b019f84f"},
+	}
+	for _, test := range tests {
+		if have := NameHash(test.name); have != common.HexToHash(test.hash) {
+			t.Errorf("%q: have %x, want %s", test.name, have, test.hash)
+		}
+	}
+}
+
+func TestENS(t *testing.T) {
+	resolver := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	owner := common.HexToAddress("0x2222222222222222222222222222222222222222")
+	other := common.HexToAddress("0x3333333333333333333333333333333333333333")
+
+	state := &FakeState{results: make(map[string]hexutil.Bytes), failures: make(map[string]string)}
+	pack := func(parsed string, method string, name string) string {
+		input, err := MustParseABI(parsed).Pack(method, NameHash(name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return hexutil.Encode(input)
+	}
+	canned := func(parsed string, method string, name string, result []byte) {
+		state.results[pack(parsed, method, name)] = result
+	}
+	reverse := func(addr common.Address) string {
+		return strings.ToLower(addr.Hex()[2:]) + ".addr.reverse"
+	}
+	for _, name := range []string{"alice.eth", "carol.eth", "dave.eth", reverse(owner), reverse(other)} {
+		canned(ensRegistryABI, "resolver", name, resolver.Hash().Bytes())
+	}
+	canned(ensRegistryABI, "resolver", "bob.eth", make([]byte, 32))
+	canned(ensResolverABI, "addr", "alice.eth", owner.Hash().Bytes())
+	canned(ensResolverABI, "name", reverse(owner), packString("alice.eth"))
+	canned(ensResolverABI, "name", reverse(other), packString("alice.eth"))
+	canned(ensResolverABI, "contenthash", "alice.eth", packString("\xe3\x01"))
+	state.failures[pack(ensResolverABI, "contenthash", "carol.eth")] = "execution reverted"
+	canned(ensResolverABI, "content", "carol.eth", common.HexToHash("0xc0").Bytes())
+	canned(ensResolverABI, "contenthash", "dave.eth", []byte{0x20})
+
+	srv := ethrpc.NewServer()
+	if err := srv.RegisterName("eth", state); err != nil {
+		t.Fatal(err)
+	}
+	c := NewClient(ethrpc.DialInProc(srv))
+	defer c.Close()
+	ens := NewENS(c, common.HexToAddress("0x00000000000000000000000000000000000000ee"))
+	ctx := context.Background()
+
+	if addr, err := ens.Resolve(ctx, "alice.eth"); err != nil || addr != owner {
+		t.Errorf("unexpected resolution: %x, %v", addr, err)
+	}
+	if addr, err := ens.Address(ctx, "alice.eth"); err != nil || addr != owner {
+		t.Errorf("unexpected resolution: %x, %v", addr, err)
+	}
+	if addr, err := ens.Address(ctx, other.Hex()); err != nil || addr != other {
+		t.Errorf("unexpected hex address: %x, %v", addr, err)
+	}
+	if name, err := ens.ReverseResolve(ctx, owner); err != nil || name != "alice.eth" {
+		t.Errorf("unexpected reverse resolution: %q, %v", name, err)
+	}
+	if _, err := ens.ReverseResolve(ctx, other); err != ErrENSReverseMismatch {
+		t.Errorf("error mismatch: have %v, want %v", err, ErrENSReverseMismatch)
+	}
+	if hash, err := ens.ContentHash(ctx, "alice.eth"); err != nil || !bytes.Equal(hash, []byte{0xe3, 0x01}) {
+		t.Errorf("unexpected content hash: %x, %v", hash, err)
+	}
+	if hash, err := ens.ContentHash(ctx, "carol.eth"); err != nil || common.BytesToHash(hash) != common.HexToHash("0xc0") {
+		t.Errorf("unexpected legacy content hash: %x, %v", hash, err)
+	}
+	if _, err := ens.ContentHash(ctx, "dave.eth"); err == nil {
+		t.Error("expected an error for a malformed content hash")
+	}
+	if _, err := ens.Resolve(ctx, "bob.eth"); err != ErrENSNotFound {
+		t.Errorf("error mismatch: have %v, want %v", err, ErrENSNotFound)
+	}
+
+	state.code = map[common.Address]hexutil.Bytes{owner: {0x60}}
+	if code, err := ens.CodeAt(ctx, "alice.eth", nil); err != nil || !bytes.Equal(code, []byte{0x60}) {
+		t.Errorf("unexpected code: %x, %v", code, err)
+	}
+}
+
+// packString ABI encodes a single dynamic string or bytes value.
+func packString(s string) []byte {
+	out := common.LeftPadBytes([]byte{0x20}, 32)
+	out = append(out, common.LeftPadBytes(big.NewInt(int64(len(s))).Bytes(), 32)...)
+	return append(out, common.RightPadBytes([]byte(s), (len(s)+31)/32*32)...)
+}
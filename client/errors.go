@@ -0,0 +1,142 @@
+// Copyright 2017 AMIS Technologies
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/url"
+	"strings"
+
+	ethrpc "github.com/ethereum/go-ethereum/rpc"
+)
+
+// Errors reported by geth and Quorum nodes when they reject a transaction.
+var (
+	ErrNonceTooLow            = errors.New("nonce too low")
+	ErrNonceTooHigh           = errors.New("nonce too high")
+	ErrKnownTransaction       = errors.New("known transaction")
+	ErrUnderpriced            = errors.New("transaction underpriced")
+	ErrReplacementUnderpriced = errors.New("replacement transaction underpriced")
+	ErrInsufficientFunds      = errors.New("insufficient funds for gas * price + value")
+	ErrIntrinsicGas           = errors.New("intrinsic gas too low")
+	ErrExceedsBlockGasLimit   = errors.New("exceeds block gas limit")
+	ErrGasLimitReached        = errors.New("gas limit reached")
+	ErrNegativeValue          = errors.New("negative value")
+	ErrOversizedData          = errors.New("oversized data")
+	ErrInvalidSender          = errors.New("invalid sender")
+	ErrInvalidGasPrice        = errors.New("gas price not 0")
+)
+
+// Errors defined by the JSON-RPC 2.0 specification.
+var (
+	ErrParse          = errors.New("parse error")
+	ErrInvalidRequest = errors.New("invalid request")
+	ErrMethodNotFound = errors.New("method not found")
+	ErrInvalidParams  = errors.New("invalid params")
+	ErrInternal       = errors.New("internal error")
+)
+
+// errorMessages maps node error messages to typed errors. More specific
+// messages must come first.
+var errorMessages = []struct {
+	msg string
+	err error
+}{
+	{"nonce too low", ErrNonceTooLow},
+	{"nonce too high", ErrNonceTooHigh},
+	{"known transaction", ErrKnownTransaction},
+	{"already known", ErrKnownTransaction},
+	{"replacement transaction underpriced", ErrReplacementUnderpriced},
+	{"transaction underpriced", ErrUnderpriced},
+	{"insufficient funds", ErrInsufficientFunds},
+	{"intrinsic gas too low", ErrIntrinsicGas},
+	{"exceeds block gas limit", ErrExceedsBlockGasLimit},
+	{"gas limit reached", ErrGasLimitReached},
+	{"negative value", ErrNegativeValue},
+	{"oversized data", ErrOversizedData},
+	{"invalid sender", ErrInvalidSender},
+	{"gas price not 0", ErrInvalidGasPrice},
+}
+
+// errorCodes maps JSON-RPC error codes to typed errors.
+var errorCodes = map[int]error{
+	-32700: ErrParse,
+	-32600: ErrInvalidRequest,
+	-32601: ErrMethodNotFound,
+	-32602: ErrInvalidParams,
+	-32603: ErrInternal,
+}
+
+// classifiedError is an error returned by the node which matches one of the
+// typed errors of this package. Its message is the node's.
+type classifiedError struct {
+	typed error
+	err   error
+}
+
+func (e *classifiedError) Error() string        { return e.err.Error() }
+func (e *classifiedError) Is(target error) bool { return target == e.typed }
+func (e *classifiedError) Unwrap() error        { return e.err }
+
+// ClassifyError wraps an error returned by the node with one of the typed
+// errors of this package, based on its message and JSON-RPC error code, so
+// that callers can test for it with errors.Is while the node's message is
+// kept as is. Errors which cannot be classified are returned unchanged.
+func ClassifyError(err error) error {
+	if err == nil || IsTransportError(err) {
+		return err
+	}
+	msg := strings.ToLower(err.Error())
+	for _, m := range errorMessages {
+		if strings.Contains(msg, m.msg) {
+			return &classifiedError{typed: m.err, err: err}
+		}
+	}
+	var rpcErr ethrpc.Error
+	if errors.As(err, &rpcErr) {
+		if typed, ok := errorCodes[rpcErr.ErrorCode()]; ok {
+			return &classifiedError{typed: typed, err: err}
+		}
+	}
+	return err
+}
+
+// IsTransportError reports whether err was caused by the connection to the
+// node rather than by the node rejecting the request. Transport errors are
+// usually worth retrying, possibly against another node.
+func IsTransportError(err error) bool {
+	if err == nil {
+		return false
+	}
+	for _, transport := range []error{io.EOF, io.ErrUnexpectedEOF, context.DeadlineExceeded, ethrpc.ErrClientQuit} {
+		if errors.Is(err, transport) {
+			return true
+		}
+	}
+	var (
+		rpcErr ethrpc.Error
+		netErr net.Error
+		urlErr *url.Error
+	)
+	if errors.As(err, &rpcErr) {
+		return false
+	}
+	return errors.As(err, &netErr) || errors.As(err, &urlErr)
+}
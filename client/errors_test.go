@@ -0,0 +1,87 @@
+// Copyright 2017 AMIS Technologies
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/big"
+	"net"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+type testRPCError struct {
+	code int
+	msg  string
+}
+
+func (e *testRPCError) Error() string  { return e.msg }
+func (e *testRPCError) ErrorCode() int { return e.code }
+
+func TestClassifyError(t *testing.T) {
+	unknown := errors.New("something else")
+	tests := []struct {
+		err       error
+		want      error
+		transport bool
+	}{
+		{err: &testRPCError{-32000, "nonce too low"}, want: ErrNonceTooLow},
+		{err: &testRPCError{-32000, "known transaction: 1a2b"}, want: ErrKnownTransaction},
+		{err: &testRPCError{-32000, "replacement transaction underpriced"}, want: ErrReplacementUnderpriced},
+		{err: &testRPCError{-32000, "transaction underpriced"}, want: ErrUnderpriced},
+		{err: &testRPCError{-32000, "insufficient funds for gas * price + value"}, want: ErrInsufficientFunds},
+		{err: &testRPCError{-32000, "Gas price not 0"}, want: ErrInvalidGasPrice},
+		{err: &testRPCError{-32601, "the method foo does not exist/is not available"}, want: ErrMethodNotFound},
+		{err: unknown, want: unknown},
+		{err: io.EOF, want: io.EOF, transport: true},
+		{err: &net.OpError{Op: "dial", Err: unknown}, transport: true},
+	}
+	for i, test := range tests {
+		if have := IsTransportError(test.err); have != test.transport {
+			t.Errorf("test %d: transport mismatch: have %v, want %v", i, have, test.transport)
+		}
+		if test.want == nil {
+			continue
+		}
+		have := ClassifyError(test.err)
+		if !errors.Is(have, test.want) {
+			t.Errorf("test %d: error mismatch: have %v, want %v", i, have, test.want)
+		}
+		if have.Error() != test.err.Error() {
+			t.Errorf("test %d: message mismatch: have %q, want %q", i, have, test.err)
+		}
+	}
+}
+
+func TestClassifyCallError(t *testing.T) {
+	c := newTestNodeClient(t, &FakeExecution{estimate: 21000, failure: "intrinsic gas too low"})
+	defer c.Close()
+
+	msg := ethereum.CallMsg{To: &common.Address{}, Gas: big.NewInt(21000)}
+	_, err := c.CallContract(context.Background(), msg, nil)
+	if !errors.Is(err, ErrIntrinsicGas) {
+		t.Errorf("error mismatch: have %v, want %v", err, ErrIntrinsicGas)
+	}
+	_, err = c.PendingCallContract(context.Background(), msg)
+	if !errors.Is(err, ErrIntrinsicGas) {
+		t.Errorf("error mismatch: have %v, want %v", err, ErrIntrinsicGas)
+	}
+}
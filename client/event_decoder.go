@@ -0,0 +1,245 @@
+// Copyright 2017 AMIS Technologies
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package client
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+)
+
+// ErrUnknownEvent is returned if no registered ABI describes a log.
+var ErrUnknownEvent = errors.New("unknown event")
+
+// ContractEvent is a log decoded according to a contract ABI. Unnamed
+// arguments are keyed by their position among the indexed or non-indexed
+// arguments. Logs of unknown events are passed through with an empty Name
+// and only Raw set.
+type ContractEvent struct {
+	Name       string
+	Address    common.Address
+	Indexed    map[string]interface{}
+	NonIndexed map[string]interface{}
+
+	BlockNumber uint64
+	BlockHash   common.Hash
+	TxHash      common.Hash
+	TxIndex     uint
+	LogIndex    uint
+	Removed     bool
+
+	Raw types.Log
+}
+
+// Known reports whether the event was decoded from a registered ABI.
+func (e *ContractEvent) Known() bool {
+	return e.Name != ""
+}
+
+// EventDecoder decodes logs with ABIs registered by contract address or by
+// event signature. Events registered by address take precedence.
+type EventDecoder struct {
+	mu          sync.RWMutex
+	byAddress   map[common.Address]map[common.Hash]abi.Event
+	bySignature map[common.Hash]abi.Event
+}
+
+// NewEventDecoder creates an empty event decoder.
+func NewEventDecoder() *EventDecoder {
+	return &EventDecoder{
+		byAddress:   make(map[common.Address]map[common.Hash]abi.Event),
+		bySignature: make(map[common.Hash]abi.Event),
+	}
+}
+
+// RegisterAddress registers the events of parsed for logs emitted by the
+// contract at address.
+func (d *EventDecoder) RegisterAddress(address common.Address, parsed abi.ABI) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	events := make(map[common.Hash]abi.Event, len(parsed.Events))
+	for _, ev := range parsed.Events {
+		if !ev.Anonymous {
+			events[ev.Id()] = ev
+		}
+	}
+	d.byAddress[address] = events
+}
+
+// RegisterABI registers the events of parsed by signature, for logs emitted
+// by any contract.
+func (d *EventDecoder) RegisterABI(parsed abi.ABI) {
+	for _, ev := range parsed.Events {
+		d.RegisterEvent(ev)
+	}
+}
+
+// RegisterEvent registers a single event by signature, for logs emitted by
+// any contract. Anonymous events cannot be matched by signature and are
+// ignored.
+func (d *EventDecoder) RegisterEvent(ev abi.Event) {
+	if ev.Anonymous {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.bySignature[ev.Id()] = ev
+}
+
+// Decode decodes a single log. It returns ErrUnknownEvent along with the raw
+// event if no registered ABI describes the log.
+func (d *EventDecoder) Decode(l types.Log) (*ContractEvent, error) {
+	e := &ContractEvent{
+		Address:     l.Address,
+		BlockNumber: l.BlockNumber,
+		BlockHash:   l.BlockHash,
+		TxHash:      l.TxHash,
+		TxIndex:     l.TxIndex,
+		LogIndex:    l.Index,
+		Removed:     l.Removed,
+		Raw:         l,
+	}
+	ev, ok := d.lookup(l)
+	if !ok {
+		return e, ErrUnknownEvent
+	}
+
+	var indexed, nonIndexed []abi.Argument
+	for _, arg := range ev.Inputs {
+		if arg.Indexed {
+			indexed = append(indexed, arg)
+		} else {
+			nonIndexed = append(nonIndexed, arg)
+		}
+	}
+	if len(l.Topics) != len(indexed)+1 {
+		return e, errors.New("event topics mismatch")
+	}
+
+	topics := make([]interface{}, len(indexed))
+	for i, arg := range indexed {
+		value, err := unpackTopic(arg, l.Topics[i+1])
+		if err != nil {
+			return e, err
+		}
+		topics[i] = value
+	}
+	e.Indexed = argumentsMap(indexed, topics)
+	values, err := unpackArguments(nonIndexed, l.Data)
+	if err != nil {
+		return e, err
+	}
+	e.NonIndexed = argumentsMap(nonIndexed, values)
+	e.Name = ev.Name
+	return e, nil
+}
+
+// DecodeLogs decodes a list of logs, passing unknown or undecodable events
+// through as raw logs.
+func (d *EventDecoder) DecodeLogs(logs []types.Log) []*ContractEvent {
+	events := make([]*ContractEvent, len(logs))
+	for i, l := range logs {
+		events[i] = d.decodeOrRaw(l)
+	}
+	return events
+}
+
+// FilterLogs executes a filter query and decodes the matching logs.
+func (d *EventDecoder) FilterLogs(ctx context.Context, client Client, q ethereum.FilterQuery) ([]*ContractEvent, error) {
+	logs, err := client.FilterLogs(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	return d.DecodeLogs(logs), nil
+}
+
+// SubscribeFilterLogs subscribes to the results of a streaming filter query
+// and delivers the decoded events on ch.
+func (d *EventDecoder) SubscribeFilterLogs(ctx context.Context, client Client, q ethereum.FilterQuery, ch chan<- *ContractEvent) (ethereum.Subscription, error) {
+	logs := make(chan types.Log)
+	sub, err := client.SubscribeFilterLogs(ctx, q, logs)
+	if err != nil {
+		return nil, err
+	}
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case l := <-logs:
+				select {
+				case ch <- d.decodeOrRaw(l):
+				case <-quit:
+					return nil
+				}
+			case err := <-sub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	}), nil
+}
+
+func (d *EventDecoder) decodeOrRaw(l types.Log) *ContractEvent {
+	e, err := d.Decode(l)
+	if err != nil && err != ErrUnknownEvent {
+		log.Debug("Failed to decode log", "address", l.Address.Hex(), "tx", l.TxHash.Hex(), "err", err)
+		e.Name, e.Indexed, e.NonIndexed = "", nil, nil
+	}
+	return e
+}
+
+func (d *EventDecoder) lookup(l types.Log) (abi.Event, bool) {
+	if len(l.Topics) == 0 {
+		return abi.Event{}, false
+	}
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if events, ok := d.byAddress[l.Address]; ok {
+		if ev, ok := events[l.Topics[0]]; ok {
+			return ev, true
+		}
+	}
+	ev, ok := d.bySignature[l.Topics[0]]
+	return ev, ok
+}
+
+// unpackTopic decodes an indexed event argument. Dynamic types are stored as
+// the hash of their value, which is returned as is.
+func unpackTopic(arg abi.Argument, topic common.Hash) (interface{}, error) {
+	switch arg.Type.T {
+	case abi.StringTy, abi.BytesTy, abi.SliceTy:
+		return topic, nil
+	}
+	if arg.Type.IsSlice || arg.Type.IsArray {
+		return topic, nil
+	}
+	arg.Indexed = false
+	values, err := unpackArguments([]abi.Argument{arg}, topic.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	return values[0], nil
+}
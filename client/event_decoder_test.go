@@ -0,0 +1,92 @@
+// Copyright 2017 AMIS Technologies
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package client
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+func TestEventDecoder(t *testing.T) {
+	parsed, err := abi.JSON(strings.NewReader(testABI))
+	if err != nil {
+		t.Fatal(err)
+	}
+	from := common.HexToAddress("0x1111")
+	to := common.HexToAddress("0x2222")
+	transfer := parsed.Events["Transfer"]
+	data, _ := packArguments(transfer.Inputs[2:], big.NewInt(100))
+
+	known := types.Log{
+		Address: common.HexToAddress("0xaaaa"),
+		Topics:  []common.Hash{transfer.Id(), from.Hash(), to.Hash()},
+		Data:    data,
+		Index:   3,
+	}
+	unknown := types.Log{
+		Address: known.Address,
+		Topics:  []common.Hash{common.HexToHash("0x01")},
+	}
+
+	d := NewEventDecoder()
+	d.RegisterAddress(known.Address, parsed)
+	events := d.DecodeLogs([]types.Log{known, unknown})
+
+	e := events[0]
+	if e.Name != "Transfer" || e.LogIndex != 3 {
+		t.Fatalf("event mismatch: have %s/%d, want Transfer/3", e.Name, e.LogIndex)
+	}
+	if e.Indexed["from"] != from || e.Indexed["to"] != to {
+		t.Errorf("indexed args mismatch: have %v", e.Indexed)
+	}
+	if v, ok := e.NonIndexed["value"].(*big.Int); !ok || v.Int64() != 100 {
+		t.Errorf("non-indexed args mismatch: have %v", e.NonIndexed)
+	}
+	if events[1].Known() || events[1].Raw.Topics[0] != unknown.Topics[0] {
+		t.Errorf("unknown event not passed through: %+v", events[1])
+	}
+}
+
+func TestEventDecoderUnnamed(t *testing.T) {
+	const unnamedABI = `[{"anonymous":false,"inputs":[{"indexed":true,"name":"","type":"address"},{"indexed":true,"name":"","type":"address"},{"indexed":false,"name":"","type":"uint256"}],"name":"Transfer","type":"event"}]`
+	parsed, err := abi.JSON(strings.NewReader(unnamedABI))
+	if err != nil {
+		t.Fatal(err)
+	}
+	from := common.HexToAddress("0x1111")
+	to := common.HexToAddress("0x2222")
+	transfer := parsed.Events["Transfer"]
+	data, _ := packArguments(transfer.Inputs[2:], big.NewInt(100))
+
+	d := NewEventDecoder()
+	d.RegisterABI(parsed)
+	e, err := d.Decode(types.Log{Topics: []common.Hash{transfer.Id(), from.Hash(), to.Hash()}, Data: data})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.Indexed["0"] != from || e.Indexed["1"] != to {
+		t.Errorf("indexed args mismatch: have %v", e.Indexed)
+	}
+	if v, ok := e.NonIndexed["0"].(*big.Int); !ok || v.Int64() != 100 {
+		t.Errorf("non-indexed args mismatch: have %v", e.NonIndexed)
+	}
+}
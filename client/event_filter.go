@@ -0,0 +1,123 @@
+// Copyright 2017 AMIS Technologies
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package client
+
+import (
+	"context"
+	"math/big"
+	"reflect"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+)
+
+// EventQuery builds a filter query for the logs of event emitted by the
+// contract at address in the given block range. topics filter the indexed
+// arguments in order; a nil entry matches any value.
+func EventQuery(address common.Address, ev abi.Event, fromBlock, toBlock *big.Int, topics ...[]common.Hash) ethereum.FilterQuery {
+	return ethereum.FilterQuery{
+		FromBlock: fromBlock,
+		ToBlock:   toBlock,
+		Addresses: []common.Address{address},
+		Topics:    append([][]common.Hash{{ev.Id()}}, topics...),
+	}
+}
+
+// AddressTopics returns the topics matching any of addrs, or nil to match
+// any address if addrs is empty.
+func AddressTopics(addrs []common.Address) []common.Hash {
+	if len(addrs) == 0 {
+		return nil
+	}
+	topics := make([]common.Hash, len(addrs))
+	for i, addr := range addrs {
+		topics[i] = addr.Hash()
+	}
+	return topics
+}
+
+// FilterEvents executes a filter query and appends the matching logs, decoded
+// with decode, to the slice events points to. decode must be a
+// func(types.Log) (T, error) and events a *[]T. Logs which do not decode are
+// skipped.
+func FilterEvents(ctx context.Context, client Client, q ethereum.FilterQuery, decode interface{}, events interface{}) error {
+	decodeLog := reflectDecoder(decode)
+	slice := reflect.ValueOf(events).Elem()
+	logs, err := client.FilterLogs(ctx, q)
+	if err != nil {
+		return err
+	}
+	for _, l := range logs {
+		ev, err := decodeLog(l)
+		if err != nil {
+			log.Warn("Skipping log", "address", l.Address.Hex(), "tx", l.TxHash.Hex(), "index", l.Index, "err", err)
+			continue
+		}
+		slice.Set(reflect.Append(slice, ev))
+	}
+	return nil
+}
+
+// SubscribeEvents subscribes to the results of a streaming filter query and
+// delivers the logs, decoded with decode, on ch. decode must be a
+// func(types.Log) (T, error) and ch a chan<- T. Logs which do not decode are
+// skipped rather than ending the subscription.
+func SubscribeEvents(ctx context.Context, client Client, q ethereum.FilterQuery, decode interface{}, ch interface{}) (ethereum.Subscription, error) {
+	decodeLog := reflectDecoder(decode)
+	send := reflect.SelectCase{Dir: reflect.SelectSend, Chan: reflect.ValueOf(ch)}
+	logs := make(chan types.Log)
+	sub, err := client.SubscribeFilterLogs(ctx, q, logs)
+	if err != nil {
+		return nil, err
+	}
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		defer sub.Unsubscribe()
+		done := reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(quit)}
+		for {
+			select {
+			case l := <-logs:
+				ev, err := decodeLog(l)
+				if err != nil {
+					log.Warn("Skipping log", "address", l.Address.Hex(), "tx", l.TxHash.Hex(), "index", l.Index, "err", err)
+					continue
+				}
+				send.Send = ev
+				reflect.Select([]reflect.SelectCase{send, done})
+			case err := <-sub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	}), nil
+}
+
+// reflectDecoder wraps decode, a func(types.Log) (T, error), so that it can
+// be called for any T.
+func reflectDecoder(decode interface{}) func(types.Log) (reflect.Value, error) {
+	fn := reflect.ValueOf(decode)
+	return func(l types.Log) (reflect.Value, error) {
+		out := fn.Call([]reflect.Value{reflect.ValueOf(l)})
+		if err, _ := out[1].Interface().(error); err != nil {
+			return reflect.Value{}, err
+		}
+		return out[0], nil
+	}
+}
@@ -0,0 +1,163 @@
+// Copyright 2017 AMIS Technologies
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	ethrpc "github.com/ethereum/go-ethereum/rpc"
+)
+
+// GasEstimator estimates the gas limit a transaction needs. A Client is
+// itself an estimator which returns the node's estimation.
+type GasEstimator interface {
+	EstimateGas(ctx context.Context, msg ethereum.CallMsg) (*big.Int, error)
+}
+
+// EstimateGasError is returned if the node fails to estimate the gas of a
+// call which fails up to the cap. Reason holds the revert reason if the
+// contract provided one, Err the failure otherwise.
+type EstimateGasError struct {
+	Cap    *big.Int
+	Reason string
+	Err    error
+}
+
+func (e *EstimateGasError) Error() string {
+	msg := fmt.Sprintf("gas estimation failed with a cap of %v", e.Cap)
+	if e.Reason != "" {
+		msg += ": " + e.Reason
+	}
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+// bufferedGasEstimator adds a safety margin on top of the node's estimation.
+type bufferedGasEstimator struct {
+	client Client
+	margin int64
+}
+
+// NewBufferedGasEstimator creates an estimator which adds margin percent to
+// the node's estimation. Estimations never exceed the gas limit of the
+// pending block.
+//
+// If the estimation fails because the call fails, it is called once at the
+// cap to return the revert reason in an *EstimateGasError. Searching the gas
+// limit with calls is not possible, as eth_call ignores the gas limit.
+func NewBufferedGasEstimator(client Client, margin int) GasEstimator {
+	return &bufferedGasEstimator{
+		client: client,
+		margin: int64(margin),
+	}
+}
+
+func (e *bufferedGasEstimator) EstimateGas(ctx context.Context, msg ethereum.CallMsg) (*big.Int, error) {
+	gasCap, err := e.pendingGasLimit(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if msg.Gas != nil && msg.Gas.Cmp(gasCap) < 0 {
+		gasCap = msg.Gas
+	}
+
+	gas, err := e.client.EstimateGas(ctx, msg)
+	if err != nil {
+		return nil, e.failure(ctx, msg, gasCap, err)
+	}
+
+	gas = new(big.Int).Mul(gas, big.NewInt(100+e.margin))
+	gas.Div(gas, big.NewInt(100))
+	if gas.Cmp(gasCap) > 0 {
+		return new(big.Int).Set(gasCap), nil
+	}
+	return gas, nil
+}
+
+// pendingGasLimit returns the gas limit of the pending block, which the
+// estimated transaction will be mined in at the earliest. The latest block
+// is used if the node has no pending block.
+func (e *bufferedGasEstimator) pendingGasLimit(ctx context.Context) (*big.Int, error) {
+	var pending *struct {
+		GasLimit *hexutil.Big `json:"gasLimit"`
+	}
+	req := []ethrpc.BatchElem{{
+		Method: "eth_getBlockByNumber",
+		Args:   []interface{}{"pending", false},
+		Result: &pending,
+	}}
+	if err := e.client.BatchCallContext(ctx, req); err != nil {
+		return nil, err
+	}
+	if req[0].Error != nil {
+		return nil, req[0].Error
+	}
+	if pending != nil && pending.GasLimit != nil {
+		return pending.GasLimit.ToInt(), nil
+	}
+	head, err := e.client.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	return head.GasLimit, nil
+}
+
+// failure returns the error of a failed estimation. Execution failures are
+// returned as an *EstimateGasError with the revert reason of a call at the
+// cap, other errors are returned unchanged.
+func (e *bufferedGasEstimator) failure(ctx context.Context, msg ethereum.CallMsg, gasCap *big.Int, err error) error {
+	if !isExecutionFailure(err) {
+		return err
+	}
+	if revert, ok := err.(*RevertError); ok {
+		return &EstimateGasError{Cap: gasCap, Reason: revert.Reason}
+	}
+
+	msg.Gas = gasCap
+	_, callErr := e.client.PendingCallContract(ctx, msg)
+	switch {
+	case callErr == nil:
+		return &EstimateGasError{Cap: gasCap, Err: err}
+	case !isExecutionFailure(callErr):
+		return callErr
+	}
+	if revert, ok := callErr.(*RevertError); ok {
+		return &EstimateGasError{Cap: gasCap, Reason: revert.Reason}
+	}
+	return &EstimateGasError{Cap: gasCap, Err: callErr}
+}
+
+// isExecutionFailure reports whether err means that a call reverted or ran
+// out of gas, rather than that the node could not execute it.
+func isExecutionFailure(err error) bool {
+	if _, ok := err.(*RevertError); ok {
+		return true
+	}
+	if errors.Is(err, ErrIntrinsicGas) {
+		return true
+	}
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "out of gas") || strings.Contains(msg, "gas required exceeds allowance")
+}
@@ -0,0 +1,139 @@
+// Copyright 2017 AMIS Technologies
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package client
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// FakeExecution is a stand-in for a node executing a call. Like the node,
+// it ignores the gas limit of calls.
+type FakeExecution struct {
+	gasLimit int64
+	// estimate is returned by eth_estimateGas, estimateFailure is its error
+	// message if estimate is zero.
+	estimate        int64
+	estimateFailure string
+	// failure is the error message of calls, result is returned by calls
+	// which do not fail.
+	failure string
+	result  hexutil.Bytes
+	// callGas records the gas limit of the last call.
+	callGas *big.Int
+}
+
+func (e *FakeExecution) GetBlockByNumber(number string, full bool) interface{} {
+	if number != "pending" {
+		return nil
+	}
+	return map[string]interface{}{"gasLimit": (*hexutil.Big)(big.NewInt(e.gasLimit))}
+}
+
+func (e *FakeExecution) EstimateGas(args map[string]interface{}) (*hexutil.Big, error) {
+	if e.estimate == 0 {
+		if e.estimateFailure != "" {
+			return nil, errors.New(e.estimateFailure)
+		}
+		return nil, errors.New("gas required exceeds allowance or always failing transaction")
+	}
+	return (*hexutil.Big)(big.NewInt(e.estimate)), nil
+}
+
+func (e *FakeExecution) Call(args map[string]interface{}, block string) (hexutil.Bytes, error) {
+	e.callGas, _ = hexutil.DecodeBig(args["gas"].(string))
+	if e.failure != "" {
+		return nil, errors.New(e.failure)
+	}
+	return e.result, nil
+}
+
+func TestBufferedGasEstimator(t *testing.T) {
+	tests := []struct {
+		exec   FakeExecution
+		margin int
+		gas    int64
+		want   int64
+		reason string
+		failed bool
+	}{
+		// The node's estimation plus the margin, capped by the pending
+		// block or the message's own gas limit.
+		{exec: FakeExecution{gasLimit: 100000, estimate: 50000}, margin: 20, want: 60000},
+		{exec: FakeExecution{gasLimit: 55000, estimate: 50000}, margin: 20, want: 55000},
+		{exec: FakeExecution{gasLimit: 100000, estimate: 50000}, margin: 20, gas: 52000, want: 52000},
+		// Failing estimations, with the revert reason of a call at the cap.
+		{exec: FakeExecution{gasLimit: 30000, failure: "execution reverted: paused"}, reason: "paused", failed: true},
+		{exec: FakeExecution{gasLimit: 30000, estimateFailure: "execution reverted: paused"}, reason: "paused", failed: true},
+		{exec: FakeExecution{gasLimit: 30000, failure: "out of gas"}, failed: true},
+		// A call which succeeds although the estimation failed must not be
+		// taken for a gas limit, since calls ignore it.
+		{exec: FakeExecution{gasLimit: 30000}, failed: true},
+		{exec: FakeExecution{gasLimit: 30000, result: revertPayload("paused")}, failed: true},
+	}
+	for i, test := range tests {
+		c := newTestNodeClient(t, &test.exec)
+		msg := ethereum.CallMsg{To: &common.Address{}}
+		if test.gas != 0 {
+			msg.Gas = big.NewInt(test.gas)
+		}
+		have, err := NewBufferedGasEstimator(c, test.margin).EstimateGas(context.Background(), msg)
+		c.Close()
+		if test.failed {
+			gasErr, ok := err.(*EstimateGasError)
+			if !ok {
+				t.Errorf("test %d: have error %v, want *EstimateGasError", i, err)
+			} else if gasErr.Reason != test.reason || gasErr.Cap.Int64() != test.exec.gasLimit {
+				t.Errorf("test %d: have reason %q and cap %v, want %q and %d", i, gasErr.Reason, gasErr.Cap, test.reason, test.exec.gasLimit)
+			}
+			if test.exec.callGas != nil && test.exec.callGas.Int64() != test.exec.gasLimit {
+				t.Errorf("test %d: have call gas %v, want %d", i, test.exec.callGas, test.exec.gasLimit)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("test %d: unexpected error: %v", i, err)
+		} else if have.Int64() != test.want {
+			t.Errorf("test %d: have gas %v, want %d", i, have, test.want)
+		}
+		if test.exec.callGas != nil {
+			t.Errorf("test %d: unexpected call with gas %v", i, test.exec.callGas)
+		}
+	}
+}
+
+func TestBufferedGasEstimatorCallError(t *testing.T) {
+	// Errors other than execution failures are returned unchanged, both from
+	// the estimation and from the call at the cap.
+	for _, exec := range []*FakeExecution{
+		{gasLimit: 100000, estimateFailure: "missing trie node"},
+		{gasLimit: 100000, failure: "missing trie node"},
+	} {
+		c := newTestNodeClient(t, exec)
+		_, err := NewBufferedGasEstimator(c, 0).EstimateGas(context.Background(), ethereum.CallMsg{To: &common.Address{}})
+		c.Close()
+		if _, ok := err.(*EstimateGasError); ok || err == nil || err.Error() != "missing trie node" {
+			t.Errorf("have error %v, want missing trie node", err)
+		}
+	}
+}
@@ -0,0 +1,152 @@
+// Copyright 2017 AMIS Technologies
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package client
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// GasPriceOracle suggests a gas price for new transactions. A Client is
+// itself an oracle which returns the node's suggestion.
+type GasPriceOracle interface {
+	SuggestGasPrice(ctx context.Context) (*big.Int, error)
+}
+
+// fixedGasPriceOracle always suggests the same gas price.
+type fixedGasPriceOracle struct {
+	price *big.Int
+}
+
+// NewFixedGasPriceOracle creates an oracle which always suggests price. Use
+// a zero or nil price for Quorum and Istanbul chains which do not charge for
+// gas.
+func NewFixedGasPriceOracle(price *big.Int) GasPriceOracle {
+	if price == nil {
+		return &fixedGasPriceOracle{price: new(big.Int)}
+	}
+	return &fixedGasPriceOracle{price: new(big.Int).Set(price)}
+}
+
+func (o *fixedGasPriceOracle) SuggestGasPrice(ctx context.Context) (*big.Int, error) {
+	return new(big.Int).Set(o.price), nil
+}
+
+// percentileGasPriceOracle suggests a percentile of the gas prices paid in
+// the most recent blocks.
+type percentileGasPriceOracle struct {
+	client     Client
+	blocks     int
+	percentile int
+}
+
+// NewPercentileGasPriceOracle creates an oracle which suggests the given
+// percentile (0-100) of the gas prices paid by the transactions in the last
+// blocks blocks. The node's suggestion is used if those blocks are empty.
+func NewPercentileGasPriceOracle(client Client, blocks int, percentile int) GasPriceOracle {
+	if blocks < 1 {
+		blocks = 1
+	}
+	if percentile < 0 {
+		percentile = 0
+	}
+	if percentile > 100 {
+		percentile = 100
+	}
+	return &percentileGasPriceOracle{
+		client:     client,
+		blocks:     blocks,
+		percentile: percentile,
+	}
+}
+
+func (o *percentileGasPriceOracle) SuggestGasPrice(ctx context.Context) (*big.Int, error) {
+	head, err := o.client.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// Fetch the recent blocks in a single batch.
+	var ids []interface{}
+	for number := head.Number.Uint64(); len(ids) < o.blocks; number-- {
+		ids = append(ids, hexutil.EncodeUint64(number))
+		if number == 0 {
+			break
+		}
+	}
+	blocks, err := batchBlocks(ctx, o.client, "eth_getBlockByNumber", ids)
+	if err != nil {
+		return nil, err
+	}
+
+	var prices []*big.Int
+	for _, block := range blocks {
+		for _, tx := range block.Transactions() {
+			prices = append(prices, tx.GasPrice())
+		}
+	}
+	if len(prices) == 0 {
+		return o.client.SuggestGasPrice(ctx)
+	}
+
+	sort.Sort(bigInts(prices))
+	return prices[(len(prices)-1)*o.percentile/100], nil
+}
+
+// clampedGasPriceOracle keeps the suggestions of another oracle in a range.
+type clampedGasPriceOracle struct {
+	oracle GasPriceOracle
+	min    *big.Int
+	max    *big.Int
+}
+
+// NewClampedGasPriceOracle creates an oracle which raises the suggestions of
+// oracle to at least min and caps them at max. A nil bound is not applied.
+func NewClampedGasPriceOracle(oracle GasPriceOracle, min, max *big.Int) (GasPriceOracle, error) {
+	if min != nil && max != nil && min.Cmp(max) > 0 {
+		return nil, errors.New("minimum gas price exceeds maximum")
+	}
+	return &clampedGasPriceOracle{
+		oracle: oracle,
+		min:    min,
+		max:    max,
+	}, nil
+}
+
+func (o *clampedGasPriceOracle) SuggestGasPrice(ctx context.Context) (*big.Int, error) {
+	price, err := o.oracle.SuggestGasPrice(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if o.min != nil && price.Cmp(o.min) < 0 {
+		return new(big.Int).Set(o.min), nil
+	}
+	if o.max != nil && price.Cmp(o.max) > 0 {
+		return new(big.Int).Set(o.max), nil
+	}
+	return price, nil
+}
+
+type bigInts []*big.Int
+
+func (s bigInts) Len() int           { return len(s) }
+func (s bigInts) Less(i, j int) bool { return s[i].Cmp(s[j]) < 0 }
+func (s bigInts) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
@@ -0,0 +1,123 @@
+// Copyright 2017 AMIS Technologies
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package client
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// newTestPriceChain creates a chain whose block i contains transactions
+// paying prices[i].
+func newTestPriceChain(t *testing.T, prices ...[]int64) *FakeChain {
+	key, _ := crypto.GenerateKey()
+	signer := types.NewEIP155Signer(big.NewInt(2017))
+	chain := &FakeChain{blocks: make([]*types.Block, len(prices))}
+	var nonce uint64
+	for i := range prices {
+		var txs []*types.Transaction
+		for _, price := range prices[i] {
+			tx, err := types.SignTx(types.NewTransaction(nonce, common.Address{}, common.Big0, big.NewInt(21000), big.NewInt(price), nil), signer, key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			nonce++
+			txs = append(txs, tx)
+		}
+		header := &types.Header{
+			Number:     big.NewInt(int64(i)),
+			Time:       big.NewInt(1500000000 + int64(i)),
+			Difficulty: common.Big1,
+			GasLimit:   big.NewInt(4700000),
+			GasUsed:    new(big.Int),
+		}
+		chain.blocks[i] = types.NewBlock(header, txs, nil, nil)
+	}
+	return chain
+}
+
+func TestFixedGasPriceOracle(t *testing.T) {
+	price := big.NewInt(5)
+	oracle := NewFixedGasPriceOracle(price)
+	price.SetInt64(6)
+	if have, _ := oracle.SuggestGasPrice(context.Background()); have.Int64() != 5 {
+		t.Errorf("price mismatch: have %v, want 5", have)
+	}
+	if have, _ := NewFixedGasPriceOracle(nil).SuggestGasPrice(context.Background()); have.Sign() != 0 {
+		t.Errorf("price mismatch: have %v, want 0", have)
+	}
+}
+
+func TestPercentileGasPriceOracle(t *testing.T) {
+	chain := newTestPriceChain(t, nil, []int64{1, 2}, []int64{3}, nil, []int64{30, 10, 20})
+	c := newTestNodeClient(t, chain)
+	defer c.Close()
+
+	for _, test := range []struct {
+		blocks, percentile int
+		want               int64
+	}{
+		{3, 50, 10},
+		{3, 0, 3},
+		{3, 100, 30},
+		{3, 200, 30},
+		{1, 0, 10},
+		{0, 100, 30},
+		{10, 50, 3},
+	} {
+		have, err := NewPercentileGasPriceOracle(c, test.blocks, test.percentile).SuggestGasPrice(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if have.Int64() != test.want {
+			t.Errorf("%d blocks, percentile %d: have %v, want %d", test.blocks, test.percentile, have, test.want)
+		}
+	}
+
+	// Empty blocks fall back to the suggestion of the node.
+	empty := newTestNodeClient(t, newTestPriceChain(t, nil, nil), &FakeTxPool{gasPrice: 42})
+	defer empty.Close()
+	if have, err := NewPercentileGasPriceOracle(empty, 5, 50).SuggestGasPrice(context.Background()); err != nil || have.Int64() != 42 {
+		t.Errorf("fallback mismatch: have %v (%v), want 42", have, err)
+	}
+}
+
+func TestClampedGasPriceOracle(t *testing.T) {
+	if _, err := NewClampedGasPriceOracle(NewFixedGasPriceOracle(nil), big.NewInt(2), big.NewInt(1)); err == nil {
+		t.Error("expected an error for an empty range")
+	}
+	for _, test := range []struct {
+		price, want int64
+	}{
+		{0, 10},
+		{15, 15},
+		{25, 20},
+	} {
+		oracle, err := NewClampedGasPriceOracle(NewFixedGasPriceOracle(big.NewInt(test.price)), big.NewInt(10), big.NewInt(20))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if have, _ := oracle.SuggestGasPrice(context.Background()); have.Int64() != test.want {
+			t.Errorf("price %d: have %v, want %d", test.price, have, test.want)
+		}
+	}
+}
@@ -0,0 +1,263 @@
+// Copyright 2017 AMIS Technologies
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// constructorName is the method name of decoded contract creations.
+const constructorName = "constructor"
+
+// ErrUnknownMethod is returned if no registered ABI or signature matches the
+// selector of a transaction input.
+var ErrUnknownMethod = errors.New("unknown method")
+
+var errInputTooShort = errors.New("input too short")
+
+// DecodedInput is a transaction input decoded according to a method or
+// constructor ABI.
+type DecodedInput struct {
+	// Method is the name of the called method, "constructor" for contract
+	// creations.
+	Method string
+	// Signature is the canonical signature of the called method.
+	Signature string
+	// Selector is the 4-byte method selector, nil for contract creations.
+	Selector []byte
+	// Names and Args hold the names and the decoded values of the arguments.
+	// Arguments of methods matched by signature only have no names.
+	Names []string
+	Args  []interface{}
+	// Bytecode is the creation bytecode without constructor arguments.
+	Bytecode []byte
+}
+
+// IsCreation reports whether the input creates a contract.
+func (d *DecodedInput) IsCreation() bool {
+	return d.Selector == nil
+}
+
+// String renders the decoded input in a human readable form, such as
+// transfer(to: 0x..., value: 100).
+func (d *DecodedInput) String() string {
+	args := make([]string, len(d.Args))
+	for i, arg := range d.Args {
+		value := formatArgument(arg)
+		if i < len(d.Names) && d.Names[i] != "" {
+			value = d.Names[i] + ": " + value
+		}
+		args[i] = value
+	}
+	return fmt.Sprintf("%s(%s)", d.Method, strings.Join(args, ", "))
+}
+
+func formatArgument(arg interface{}) string {
+	switch v := arg.(type) {
+	case common.Address:
+		return v.Hex()
+	case common.Hash:
+		return v.Hex()
+	case []byte:
+		return hexutil.Encode(v)
+	case *big.Int:
+		return v.String()
+	case string:
+		return fmt.Sprintf("%q", v)
+	}
+	return fmt.Sprintf("%v", arg)
+}
+
+type creationABI struct {
+	bytecode []byte
+	abi      abi.ABI
+}
+
+// InputDecoder decodes transaction inputs with registered ABIs and,
+// optionally, a signature database.
+type InputDecoder struct {
+	mu         sync.RWMutex
+	methods    map[string]abi.Method
+	signatures map[string]abi.Method
+	creations  []creationABI
+}
+
+// NewInputDecoder creates an empty input decoder.
+func NewInputDecoder() *InputDecoder {
+	return &InputDecoder{
+		methods:    make(map[string]abi.Method),
+		signatures: make(map[string]abi.Method),
+	}
+}
+
+// RegisterABI registers the methods of parsed.
+func (d *InputDecoder) RegisterABI(parsed abi.ABI) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for _, method := range parsed.Methods {
+		d.methods[string(method.Id())] = method
+	}
+}
+
+// RegisterBytecode registers the creation bytecode of a contract, so that its
+// constructor arguments can be split from the bytecode of contract creation
+// transactions. The methods of parsed are registered as well.
+func (d *InputDecoder) RegisterBytecode(bytecode []byte, parsed abi.ABI) {
+	d.RegisterABI(parsed)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.creations = append(d.creations, creationABI{bytecode: common.CopyBytes(bytecode), abi: parsed})
+}
+
+// LoadSignatures loads a signature database from a JSON file mapping hex
+// selectors to text signatures, as in {"a9059cbb": "transfer(address,uint256)"}.
+// Signatures are only used for selectors without a registered ABI.
+func (d *InputDecoder) LoadSignatures(path string) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var db map[string]string
+	if err := json.Unmarshal(data, &db); err != nil {
+		return err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for selector, sig := range db {
+		method, err := parseMethodSignature(sig)
+		if err != nil {
+			log.Debug("Skipping invalid signature", "selector", selector, "signature", sig, "err", err)
+			continue
+		}
+		id, err := hexutil.Decode("0x" + strings.TrimPrefix(selector, "0x"))
+		if err != nil || !bytes.Equal(id, method.Id()) {
+			log.Debug("Skipping mismatching signature", "selector", selector, "signature", sig)
+			continue
+		}
+		d.signatures[string(id)] = method
+	}
+	return nil
+}
+
+// Decode decodes the input of a transaction.
+func (d *InputDecoder) Decode(tx *types.Transaction) (*DecodedInput, error) {
+	return d.DecodeInput(tx.To() == nil, tx.Data())
+}
+
+// DecodeInput decodes a transaction input. If create is set, the input is
+// treated as the bytecode of a contract creation.
+func (d *InputDecoder) DecodeInput(create bool, input []byte) (*DecodedInput, error) {
+	if create {
+		return d.decodeCreation(input)
+	}
+	if len(input) < 4 {
+		return nil, errInputTooShort
+	}
+
+	d.mu.RLock()
+	method, ok := d.methods[string(input[:4])]
+	if !ok {
+		method, ok = d.signatures[string(input[:4])]
+	}
+	d.mu.RUnlock()
+	if !ok {
+		return &DecodedInput{Selector: common.CopyBytes(input[:4])}, ErrUnknownMethod
+	}
+
+	args, err := unpackArguments(method.Inputs, input[4:])
+	if err != nil {
+		return nil, err
+	}
+	return &DecodedInput{
+		Method:    method.Name,
+		Signature: method.Sig(),
+		Selector:  method.Id(),
+		Names:     argumentNames(method.Inputs),
+		Args:      args,
+	}, nil
+}
+
+func (d *InputDecoder) decodeCreation(input []byte) (*DecodedInput, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	for _, c := range d.creations {
+		if !bytes.HasPrefix(input, c.bytecode) {
+			continue
+		}
+		constructor := c.abi.Constructor
+		constructor.Name = constructorName
+		args, err := unpackArguments(constructor.Inputs, input[len(c.bytecode):])
+		if err != nil {
+			return nil, err
+		}
+		return &DecodedInput{
+			Method:    constructor.Name,
+			Signature: constructor.Sig(),
+			Names:     argumentNames(constructor.Inputs),
+			Args:      args,
+			Bytecode:  c.bytecode,
+		}, nil
+	}
+	// Without a known bytecode the constructor arguments cannot be told apart.
+	return &DecodedInput{Method: constructorName, Bytecode: input}, nil
+}
+
+func argumentNames(args []abi.Argument) []string {
+	names := make([]string, len(args))
+	for i, arg := range args {
+		names[i] = arg.Name
+	}
+	return names
+}
+
+// parseMethodSignature builds a method from a text signature such as
+// transfer(address,uint256).
+func parseMethodSignature(sig string) (abi.Method, error) {
+	open := strings.Index(sig, "(")
+	if open <= 0 || !strings.HasSuffix(sig, ")") {
+		return abi.Method{}, fmt.Errorf("invalid signature %q", sig)
+	}
+	method := abi.Method{Name: sig[:open]}
+	params := sig[open+1 : len(sig)-1]
+	if params == "" {
+		return method, nil
+	}
+	for _, param := range strings.Split(params, ",") {
+		typ, err := abi.NewType(strings.TrimSpace(param))
+		if err != nil {
+			return abi.Method{}, err
+		}
+		method.Inputs = append(method.Inputs, abi.Argument{Type: typ})
+	}
+	return method, nil
+}
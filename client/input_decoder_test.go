@@ -0,0 +1,140 @@
+// Copyright 2017 AMIS Technologies
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package client
+
+import (
+	"bytes"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+const testCreationABI = `[
+	{"type":"constructor","inputs":[{"name":"supply","type":"uint256"},{"name":"name","type":"string"}]}
+]`
+
+func TestInputDecoder(t *testing.T) {
+	dir, err := ioutil.TempDir("", "signatures")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	db := filepath.Join(dir, "signatures.json")
+	signatures := `{
+		"a9059cbb": "transfer(address,uint256)",
+		"0x70a08231": "balanceOf(address)",
+		"deadbeef": "transfer(address,uint256)",
+		"12345678": "broken("
+	}`
+	if err := ioutil.WriteFile(db, []byte(signatures), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	parsed, _ := abi.JSON(strings.NewReader(testABI))
+	creation, _ := abi.JSON(strings.NewReader(testCreationABI))
+	bytecode := []byte{0x60, 0x80, 0x60, 0x40, 0x52}
+	decoder := NewInputDecoder()
+	decoder.RegisterABI(parsed)
+	decoder.RegisterBytecode(bytecode, creation)
+	if err := decoder.LoadSignatures(db); err != nil {
+		t.Fatal(err)
+	}
+
+	owner := common.HexToAddress("0x00000000000000000000000000000000000000aa")
+	balanceOf, _ := parsed.Pack("balanceOf", owner)
+	transfer := append(common.FromHex("a9059cbb"), common.LeftPadBytes(owner.Bytes(), 32)...)
+	transfer = append(transfer, common.LeftPadBytes(big.NewInt(100).Bytes(), 32)...)
+	constructorArgs, _ := creation.Pack("", big.NewInt(5), "amis")
+
+	tests := []struct {
+		create    bool
+		input     []byte
+		method    string
+		signature string
+		str       string
+		err       error
+	}{
+		// Registered ABIs decode with argument names and take precedence
+		// over the signature database.
+		{
+			input:     balanceOf,
+			method:    "balanceOf",
+			signature: "balanceOf(address)",
+			str:       "balanceOf(owner: " + owner.Hex() + ")",
+		},
+		{
+			input:     transfer,
+			method:    "transfer",
+			signature: "transfer(address,uint256)",
+			str:       "transfer(" + owner.Hex() + ", 100)",
+		},
+		{input: append(common.FromHex("deadbeef"), transfer[4:]...), err: ErrUnknownMethod},
+		{input: common.FromHex("12345678"), err: ErrUnknownMethod},
+		{input: []byte{1, 2}, err: errInputTooShort},
+		// Contract creations.
+		{
+			create:    true,
+			input:     append(append([]byte{}, bytecode...), constructorArgs...),
+			method:    "constructor",
+			signature: "constructor(uint256,string)",
+			str:       `constructor(supply: 5, name: "amis")`,
+		},
+		{
+			create: true,
+			input:  []byte{0x60, 0x01},
+			method: "constructor",
+			str:    "constructor()",
+		},
+	}
+	for i, test := range tests {
+		decoded, err := decoder.DecodeInput(test.create, test.input)
+		if test.err != nil {
+			if err != test.err {
+				t.Errorf("test %d: error mismatch: have %v, want %v", i, err, test.err)
+			}
+			if err == ErrUnknownMethod && !bytes.Equal(decoded.Selector, test.input[:4]) {
+				t.Errorf("test %d: selector mismatch: have %x, want %x", i, decoded.Selector, test.input[:4])
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("test %d: unexpected error: %v", i, err)
+			continue
+		}
+		if decoded.Method != test.method || decoded.Signature != test.signature {
+			t.Errorf("test %d: method mismatch: have %s %s, want %s %s", i, decoded.Method, decoded.Signature, test.method, test.signature)
+		}
+		if decoded.IsCreation() != test.create {
+			t.Errorf("test %d: creation mismatch: have %v, want %v", i, decoded.IsCreation(), test.create)
+		}
+		if have := decoded.String(); have != test.str {
+			t.Errorf("test %d: string mismatch: have %s, want %s", i, have, test.str)
+		}
+	}
+
+	// The bytecode of unknown creations is kept whole.
+	decoded, _ := decoder.DecodeInput(true, []byte{0x60, 0x01})
+	if !bytes.Equal(decoded.Bytecode, []byte{0x60, 0x01}) {
+		t.Errorf("bytecode mismatch: have %x", decoded.Bytecode)
+	}
+}
@@ -0,0 +1,351 @@
+// Copyright 2017 AMIS Technologies
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// placeholderLength is the length of a library placeholder in solc output.
+const placeholderLength = 40
+
+// CompiledContract is a contract of solc's combined-json output. Its
+// bytecodes are hex encoded and may contain library placeholders.
+type CompiledContract struct {
+	Name       string
+	ABI        abi.ABI
+	Bin        string
+	BinRuntime string
+}
+
+// ParseCombinedJSON parses the output of solc --combined-json abi,bin,bin-runtime
+// and returns the contracts keyed by their fully qualified name, such as
+// contracts/Math.sol:Math.
+func ParseCombinedJSON(data []byte) (map[string]*CompiledContract, error) {
+	var output struct {
+		Contracts map[string]struct {
+			ABI        json.RawMessage `json:"abi"`
+			Bin        string          `json:"bin"`
+			BinRuntime string          `json:"bin-runtime"`
+		} `json:"contracts"`
+	}
+	if err := json.Unmarshal(data, &output); err != nil {
+		return nil, err
+	}
+
+	contracts := make(map[string]*CompiledContract, len(output.Contracts))
+	for name, c := range output.Contracts {
+		// Older solc versions encode the ABI as a JSON string.
+		abiJSON := []byte(c.ABI)
+		var s string
+		if err := json.Unmarshal(c.ABI, &s); err == nil {
+			abiJSON = []byte(s)
+		}
+		parsed, err := abi.JSON(bytes.NewReader(abiJSON))
+		if err != nil {
+			return nil, fmt.Errorf("invalid ABI of %s: %v", name, err)
+		}
+		contracts[name] = &CompiledContract{
+			Name:       name,
+			ABI:        parsed,
+			Bin:        strings.TrimPrefix(c.Bin, "0x"),
+			BinRuntime: strings.TrimPrefix(c.BinRuntime, "0x"),
+		}
+	}
+	return contracts, nil
+}
+
+// Libraries returns the fully qualified names of the libraries the contract
+// must be linked against, out of the names of all known contracts.
+func (c *CompiledContract) Libraries(names []string) ([]string, error) {
+	var libs []string
+	seen := make(map[string]bool)
+	for _, placeholder := range placeholders(c.Bin) {
+		name, ok := matchPlaceholder(placeholder, names)
+		if !ok {
+			return nil, fmt.Errorf("unknown library placeholder %s in %s", placeholder, c.Name)
+		}
+		if !seen[name] {
+			seen[name] = true
+			libs = append(libs, name)
+		}
+	}
+	sort.Strings(libs)
+	return libs, nil
+}
+
+// Link replaces the library placeholders of a hex encoded bytecode with the
+// given library addresses and returns the decoded bytecode.
+func Link(bin string, addresses map[string]common.Address) ([]byte, error) {
+	names := make([]string, 0, len(addresses))
+	for name := range addresses {
+		names = append(names, name)
+	}
+	for _, placeholder := range placeholders(bin) {
+		name, ok := matchPlaceholder(placeholder, names)
+		if !ok {
+			return nil, fmt.Errorf("unlinked library placeholder %s", placeholder)
+		}
+		bin = strings.Replace(bin, placeholder, common.Bytes2Hex(addresses[name].Bytes()), -1)
+	}
+	return hexutil.Decode("0x" + bin)
+}
+
+// placeholders returns the distinct library placeholders in a hex encoded
+// bytecode.
+func placeholders(bin string) []string {
+	var result []string
+	seen := make(map[string]bool)
+	for i := strings.Index(bin, "__"); i >= 0 && i+placeholderLength <= len(bin); {
+		placeholder := bin[i : i+placeholderLength]
+		if !seen[placeholder] {
+			seen[placeholder] = true
+			result = append(result, placeholder)
+		}
+		next := strings.Index(bin[i+placeholderLength:], "__")
+		if next < 0 {
+			break
+		}
+		i += placeholderLength + next
+	}
+	return result
+}
+
+// matchPlaceholder finds the library a placeholder refers to. solc < 0.5 uses
+// the fully qualified name truncated to 36 characters and padded with
+// underscores, later versions use __$ and the first 34 hex characters of its
+// keccak256 hash followed by $__.
+func matchPlaceholder(placeholder string, names []string) (string, bool) {
+	for _, name := range names {
+		if strings.HasPrefix(placeholder, "__$") {
+			hash := common.Bytes2Hex(crypto.Keccak256([]byte(name)))[:34]
+			if placeholder == "__$"+hash+"$__" {
+				return name, true
+			}
+			continue
+		}
+		id := name
+		if len(id) > placeholderLength-4 {
+			id = id[:placeholderLength-4]
+		}
+		if placeholder == "__"+id+strings.Repeat("_", placeholderLength-2-len(id)) {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// DeploymentPlan deploys contracts together with the libraries they depend
+// on. Libraries are deployed in dependency order and linked into dependent
+// bytecode. Sent creations and deployed addresses are saved in a state file,
+// so that an interrupted plan resumes where it stopped: deployed contracts
+// are skipped and pending creations are waited for instead of being sent
+// again.
+type DeploymentPlan struct {
+	deployer  *Deployer
+	contracts map[string]*CompiledContract
+	statePath string
+	state     deploymentState
+}
+
+// deploymentState is the saved progress of a deployment plan.
+type deploymentState struct {
+	Addresses map[string]common.Address    `json:"addresses"`
+	Pending   map[string]pendingDeployment `json:"pending,omitempty"`
+}
+
+// pendingDeployment is a contract creation which has been sent but not yet
+// verified.
+type pendingDeployment struct {
+	Hash    common.Hash    `json:"hash"`
+	Address common.Address `json:"address"`
+}
+
+// NewDeploymentPlan creates a plan for the given compiled contracts. If
+// statePath is not empty, the progress of earlier deployments is loaded from
+// and saved to it.
+func NewDeploymentPlan(deployer *Deployer, contracts map[string]*CompiledContract, statePath string) (*DeploymentPlan, error) {
+	p := &DeploymentPlan{
+		deployer:  deployer,
+		contracts: contracts,
+		statePath: statePath,
+	}
+	if statePath != "" {
+		data, err := ioutil.ReadFile(statePath)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		if err == nil {
+			if err := json.Unmarshal(data, &p.state); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if p.state.Addresses == nil {
+		p.state.Addresses = make(map[string]common.Address)
+	}
+	if p.state.Pending == nil {
+		p.state.Pending = make(map[string]pendingDeployment)
+	}
+	return p, nil
+}
+
+// Addresses returns the addresses of the contracts deployed so far.
+func (p *DeploymentPlan) Addresses() map[string]common.Address {
+	addresses := make(map[string]common.Address, len(p.state.Addresses))
+	for name, addr := range p.state.Addresses {
+		addresses[name] = addr
+	}
+	return addresses
+}
+
+// Order returns the contracts which must be deployed for name, libraries
+// first and name last.
+func (p *DeploymentPlan) Order(name string) ([]string, error) {
+	var (
+		order    []string
+		visiting = make(map[string]bool)
+		done     = make(map[string]bool)
+		visit    func(string) error
+	)
+	names := make([]string, 0, len(p.contracts))
+	for n := range p.contracts {
+		names = append(names, n)
+	}
+	visit = func(n string) error {
+		if done[n] {
+			return nil
+		}
+		if visiting[n] {
+			return fmt.Errorf("library dependency cycle at %s", n)
+		}
+		c, ok := p.contracts[n]
+		if !ok {
+			return fmt.Errorf("unknown contract %s", n)
+		}
+		visiting[n] = true
+		libs, err := c.Libraries(names)
+		if err != nil {
+			return err
+		}
+		for _, lib := range libs {
+			if err := visit(lib); err != nil {
+				return err
+			}
+		}
+		visiting[n] = false
+		done[n] = true
+		order = append(order, n)
+		return nil
+	}
+	if err := visit(name); err != nil {
+		return nil, err
+	}
+	return order, nil
+}
+
+// Deploy deploys the contract name with the given constructor args, after
+// the libraries it depends on. Contracts recorded in the state are not
+// deployed again, and for creations sent by an interrupted run the plan
+// waits until they have been mined.
+func (p *DeploymentPlan) Deploy(ctx context.Context, name string, args ...interface{}) (*Contract, error) {
+	order, err := p.Order(name)
+	if err != nil {
+		return nil, err
+	}
+	for _, n := range order {
+		if address, ok := p.state.Addresses[n]; ok {
+			log.Debug("Skipping deployed contract", "name", n, "address", address.Hex())
+			continue
+		}
+		if err := p.deploy(ctx, n, n == name, args); err != nil {
+			return nil, fmt.Errorf("failed to deploy %s: %v", n, err)
+		}
+	}
+	return NewContractWithABI(p.deployer.transactor.client, p.contracts[name].ABI, p.state.Addresses[name]), nil
+}
+
+// deploy deploys the contract name, or waits for its pending creation, and
+// records its address. Only the target contract gets the constructor args.
+func (p *DeploymentPlan) deploy(ctx context.Context, name string, target bool, args []interface{}) error {
+	c := p.contracts[name]
+	runtime, err := Link(c.BinRuntime, p.state.Addresses)
+	if err != nil {
+		return err
+	}
+	pending, ok := p.state.Pending[name]
+	if ok {
+		log.Info("Waiting for pending contract creation", "name", name, "hash", pending.Hash.Hex())
+	} else {
+		bytecode, err := Link(c.Bin, p.state.Addresses)
+		if err != nil {
+			return err
+		}
+		var ctorArgs []interface{}
+		if target {
+			ctorArgs = args
+		}
+		address, tx, err := p.deployer.send(ctx, c.ABI, bytecode, ctorArgs...)
+		if err != nil {
+			return err
+		}
+		pending = pendingDeployment{Hash: tx.Hash(), Address: address}
+		p.state.Pending[name] = pending
+		if err := p.save(); err != nil {
+			return err
+		}
+	}
+
+	_, _, err = p.deployer.wait(ctx, c.ABI, pending.Address, pending.Hash, runtime)
+	if err == ErrDeployFailed {
+		// A failed creation is sent again by the next run.
+		delete(p.state.Pending, name)
+		if saveErr := p.save(); saveErr != nil {
+			return saveErr
+		}
+	}
+	if err != nil {
+		return err
+	}
+	log.Info("Contract deployed", "name", name, "address", pending.Address.Hex())
+	delete(p.state.Pending, name)
+	p.state.Addresses[name] = pending.Address
+	return p.save()
+}
+
+func (p *DeploymentPlan) save() error {
+	if p.statePath == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(p.state, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(p.statePath, data)
+}
@@ -0,0 +1,183 @@
+// Copyright 2017 AMIS Technologies
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func testPlaceholder(name string) string {
+	return "__" + name + strings.Repeat("_", placeholderLength-2-len(name))
+}
+
+func TestDeploymentPlanOrder(t *testing.T) {
+	combined, _ := json.Marshal(map[string]interface{}{
+		"contracts": map[string]interface{}{
+			"lib.sol:Math":    map[string]string{"abi": "[]", "bin": "6001", "bin-runtime": "6001"},
+			"lib.sol:Strings": map[string]string{"abi": "[]", "bin": "73" + testPlaceholder("lib.sol:Math"), "bin-runtime": "00"},
+			"token.sol:Token": map[string]string{
+				"abi":         "[]",
+				"bin":         "73" + testPlaceholder("lib.sol:Strings") + "73" + testPlaceholder("lib.sol:Math"),
+				"bin-runtime": "00",
+			},
+		},
+	})
+	contracts, err := ParseCombinedJSON(combined)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	plan, err := NewDeploymentPlan(nil, contracts, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	order, err := plan.Order("token.sol:Token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"lib.sol:Math", "lib.sol:Strings", "token.sol:Token"}
+	if !reflect.DeepEqual(order, want) {
+		t.Errorf("order mismatch: have %v, want %v", order, want)
+	}
+
+	math := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	strs := common.HexToAddress("0x2222222222222222222222222222222222222222")
+	linked, err := Link(contracts["token.sol:Token"].Bin, map[string]common.Address{
+		"lib.sol:Math":    math,
+		"lib.sol:Strings": strs,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantCode := append(append([]byte{0x73}, strs.Bytes()...), append([]byte{0x73}, math.Bytes()...)...)
+	if !bytes.Equal(linked, wantCode) {
+		t.Errorf("linked code mismatch: have %x, want %x", linked, wantCode)
+	}
+	if _, err := Link(contracts["token.sol:Token"].Bin, map[string]common.Address{"lib.sol:Math": math}); err == nil {
+		t.Error("expected an error for an unlinked library")
+	}
+}
+
+func TestDeploymentPlanDeploy(t *testing.T) {
+	combined, _ := json.Marshal(map[string]interface{}{
+		"contracts": map[string]interface{}{
+			// Libraries start with a PUSH20 of their own address.
+			"lib.sol:Math": map[string]string{"abi": "[]", "bin": "6001", "bin-runtime": "73" + strings.Repeat("00", 20) + "00"},
+			"token.sol:Token": map[string]string{
+				"abi":         `[{"type":"constructor","inputs":[{"name":"x","type":"uint256"}]}]`,
+				"bin":         "73" + testPlaceholder("lib.sol:Math"),
+				"bin-runtime": "6000" + "73" + testPlaceholder("lib.sol:Math"),
+			},
+		},
+	})
+	contracts, err := ParseCombinedJSON(combined)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir, err := ioutil.TempDir("", "deployment")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	key, _ := crypto.GenerateKey()
+	from := crypto.PubkeyToAddress(key.PublicKey)
+	math, token := crypto.CreateAddress(from, 0), crypto.CreateAddress(from, 1)
+	state := &FakeState{code: map[common.Address]hexutil.Bytes{
+		math:  append(append([]byte{0x73}, math.Bytes()...), 0x00),
+		token: append([]byte{0x60, 0x00, 0x73}, math.Bytes()...),
+	}}
+	pool := &FakeTxPool{gasPrice: 1}
+	newPlan := func(t *testing.T, path string, pool interface{}) (*DeploymentPlan, func()) {
+		c := newTestNodeClient(t, pool, state, &FakeExecution{estimate: 50000})
+		plan, err := NewDeploymentPlan(NewDeployer(NewTransactor(c, NewKeySigner(key), big.NewInt(2017))), contracts, path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return plan, c.Close
+	}
+
+	// An interrupted run leaves the library creation pending.
+	path := filepath.Join(dir, "state.json")
+	plan, closeClient := newPlan(t, path, pool)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	if _, err := plan.Deploy(ctx, "token.sol:Token", big.NewInt(1)); err == nil {
+		t.Fatal("expected an error for an unmined creation")
+	}
+	cancel()
+	closeClient()
+	if len(pool.sent) != 1 {
+		t.Fatalf("sent transaction count mismatch: have %d, want 1", len(pool.sent))
+	}
+	saved, err := NewDeploymentPlan(nil, contracts, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := pendingDeployment{Hash: pool.sent[0], Address: math}
+	if have := saved.state.Pending["lib.sol:Math"]; have != want {
+		t.Errorf("pending creation mismatch: have %+v, want %+v", have, want)
+	}
+
+	// The resumed run waits for the pending creation instead of sending it
+	// again, and verifies the library code with its own address in it.
+	pool.mine(pool.pool[pool.sent[0]], types.ReceiptStatusSuccessful)
+	pool.nonce++
+	plan, closeClient = newPlan(t, path, &FakeMiner{FakeTxPool: pool, status: types.ReceiptStatusSuccessful})
+	defer closeClient()
+	contract, err := plan.Deploy(context.Background(), "token.sol:Token", big.NewInt(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if contract.Address() != token {
+		t.Errorf("contract address mismatch: have %x, want %x", contract.Address(), token)
+	}
+	if len(pool.sent) != 2 {
+		t.Errorf("sent transaction count mismatch: have %d, want 2", len(pool.sent))
+	}
+	wantAddresses := map[string]common.Address{"lib.sol:Math": math, "token.sol:Token": token}
+	if have := plan.Addresses(); !reflect.DeepEqual(have, wantAddresses) {
+		t.Errorf("addresses mismatch: have %v, want %v", have, wantAddresses)
+	}
+
+	// A finished plan deploys nothing.
+	plan, closeClient = newPlan(t, path, pool)
+	defer closeClient()
+	if len(plan.state.Pending) != 0 {
+		t.Errorf("pending creations left: %v", plan.state.Pending)
+	}
+	if _, err := plan.Deploy(context.Background(), "token.sol:Token", big.NewInt(1)); err != nil {
+		t.Fatal(err)
+	}
+	if len(pool.sent) != 2 {
+		t.Errorf("sent transaction count mismatch: have %d, want 2", len(pool.sent))
+	}
+}
@@ -0,0 +1,318 @@
+// Copyright 2017 AMIS Technologies
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package client
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// LogFetcherConfig contains the settings of a log fetcher.
+type LogFetcherConfig struct {
+	// InitialChunk is the number of blocks requested at once to begin with.
+	InitialChunk uint64
+	// MinChunk and MaxChunk bound the adapted chunk size.
+	MinChunk uint64
+	MaxChunk uint64
+	// GrowThreshold is the number of logs below which a response is
+	// considered small and the chunk size is doubled.
+	GrowThreshold int
+	// Concurrency is the maximum number of requests in flight.
+	Concurrency int
+	// RequestTimeout bounds a single request; a timed out request is retried
+	// as two smaller ones. Zero means no timeout.
+	RequestTimeout time.Duration
+}
+
+// DefaultLogFetcherConfig contains the default log fetcher settings.
+var DefaultLogFetcherConfig = LogFetcherConfig{
+	InitialChunk:   1000,
+	MinChunk:       1,
+	MaxChunk:       100000,
+	GrowThreshold:  1000,
+	Concurrency:    4,
+	RequestTimeout: 30 * time.Second,
+}
+
+// rangeTooLargeMessages are node error messages telling that a log query
+// covers too many blocks or results, by geth, parity and hosted nodes.
+var rangeTooLargeMessages = []string{
+	"query returned more than",
+	"too many results",
+	"response size exceeded",
+	"response size should not greater than",
+	"query timeout exceeded",
+	"log response size exceeded",
+	"block range is too wide",
+	"limit exceeded",
+}
+
+// LogFetcher fetches logs of large block ranges by splitting them into
+// chunks, adapting the chunk size to the responses of the node.
+type LogFetcher struct {
+	client Client
+	config LogFetcherConfig
+}
+
+// NewLogFetcher creates a log fetcher on top of client. Unset settings take
+// their default values.
+func NewLogFetcher(client Client, config LogFetcherConfig) *LogFetcher {
+	if config.InitialChunk == 0 {
+		config.InitialChunk = DefaultLogFetcherConfig.InitialChunk
+	}
+	if config.MinChunk == 0 {
+		config.MinChunk = DefaultLogFetcherConfig.MinChunk
+	}
+	if config.MaxChunk == 0 {
+		config.MaxChunk = DefaultLogFetcherConfig.MaxChunk
+	}
+	if config.GrowThreshold == 0 {
+		config.GrowThreshold = DefaultLogFetcherConfig.GrowThreshold
+	}
+	if config.Concurrency <= 0 {
+		config.Concurrency = DefaultLogFetcherConfig.Concurrency
+	}
+	return &LogFetcher{
+		client: client,
+		config: config,
+	}
+}
+
+// FilterLogs executes the filter query over its whole block range and
+// returns the logs in canonical order.
+func (f *LogFetcher) FilterLogs(ctx context.Context, q ethereum.FilterQuery) ([]types.Log, error) {
+	it := f.Iterator(ctx, q)
+	defer it.Close()
+
+	var logs []types.Log
+	for it.Next() {
+		logs = append(logs, it.Log())
+	}
+	return logs, it.Err()
+}
+
+// Iterator executes the filter query in the background and streams the logs
+// in canonical order. A nil ToBlock is the latest block at the time of the
+// call.
+func (f *LogFetcher) Iterator(ctx context.Context, q ethereum.FilterQuery) *LogIterator {
+	ctx, cancel := context.WithCancel(ctx)
+	it := &LogIterator{
+		logs:   make(chan types.Log, f.config.GrowThreshold),
+		cancel: cancel,
+	}
+	go func() {
+		defer close(it.logs)
+		it.err = f.run(ctx, q, it.logs)
+	}()
+	return it
+}
+
+// LogIterator streams the logs of a LogFetcher query.
+type LogIterator struct {
+	logs   chan types.Log
+	cancel context.CancelFunc
+	log    types.Log
+	err    error
+}
+
+// Next advances to the next log. It returns false when all logs have been
+// read or an error occurred, see Err.
+func (it *LogIterator) Next() bool {
+	log, ok := <-it.logs
+	if !ok {
+		return false
+	}
+	it.log = log
+	return true
+}
+
+// Log returns the current log.
+func (it *LogIterator) Log() types.Log {
+	return it.log
+}
+
+// Err returns the error which stopped the iteration, if any. It must only be
+// called after Next returned false.
+func (it *LogIterator) Err() error {
+	return it.err
+}
+
+// Close stops the query. It must be called if the iteration is abandoned
+// before Next returned false.
+func (it *LogIterator) Close() {
+	it.cancel()
+	for range it.logs {
+	}
+}
+
+type logRange struct {
+	from, to uint64
+}
+
+type logResult struct {
+	r    logRange
+	logs []types.Log
+	err  error
+}
+
+// run fetches the logs of q, sending them to out in order.
+func (f *LogFetcher) run(ctx context.Context, q ethereum.FilterQuery, out chan<- types.Log) error {
+	var from, to uint64
+	if q.FromBlock != nil {
+		from = q.FromBlock.Uint64()
+	}
+	if q.ToBlock != nil {
+		to = q.ToBlock.Uint64()
+	} else {
+		head, err := f.client.BlockNumber(ctx)
+		if err != nil {
+			return err
+		}
+		to = head.Uint64()
+	}
+	if from > to {
+		return nil
+	}
+
+	var (
+		results  = make(chan logResult)
+		chunk    = f.config.InitialChunk
+		cursor   = from
+		next     = from
+		retry    []logRange
+		done     = make(map[uint64]logResult)
+		inflight int
+	)
+	defer func() {
+		// Let the requests in flight finish before returning.
+		for ; inflight > 0; inflight-- {
+			<-results
+		}
+	}()
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	for {
+		// Retried ranges come first as they hold back the output. New ranges
+		// are only started while few finished ones wait to be sent.
+		for inflight < f.config.Concurrency {
+			var r logRange
+			if len(retry) > 0 {
+				r, retry = retry[0], retry[1:]
+			} else if cursor <= to && len(done) < 2*f.config.Concurrency {
+				r = logRange{cursor, to}
+				if to-cursor >= chunk {
+					r.to = cursor + chunk - 1
+				}
+				cursor = r.to + 1
+			} else {
+				break
+			}
+			inflight++
+			go f.fetch(ctx, q, r, results)
+		}
+		if inflight == 0 {
+			return nil
+		}
+
+		res := <-results
+		inflight--
+		size := res.r.to - res.r.from + 1
+		if res.err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if !isRangeTooLarge(res.err) || size == 1 {
+				return res.err
+			}
+			if chunk = size / 2; chunk < f.config.MinChunk {
+				chunk = f.config.MinChunk
+			}
+			mid := res.r.from + size/2 - 1
+			log.Debug("Splitting log range", "from", res.r.from, "to", res.r.to, "err", res.err)
+			retry = append(retry, logRange{res.r.from, mid}, logRange{mid + 1, res.r.to})
+			sort.Sort(logRanges(retry))
+			continue
+		}
+		if len(res.logs) < f.config.GrowThreshold && size >= chunk && chunk < f.config.MaxChunk {
+			if chunk *= 2; chunk > f.config.MaxChunk {
+				chunk = f.config.MaxChunk
+			}
+		}
+
+		done[res.r.from] = res
+		for {
+			res, ok := done[next]
+			if !ok {
+				break
+			}
+			delete(done, next)
+			for _, l := range res.logs {
+				select {
+				case out <- l:
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			}
+			next = res.r.to + 1
+		}
+	}
+}
+
+func (f *LogFetcher) fetch(ctx context.Context, q ethereum.FilterQuery, r logRange, results chan<- logResult) {
+	reqCtx := ctx
+	if f.config.RequestTimeout > 0 {
+		var cancel context.CancelFunc
+		reqCtx, cancel = context.WithTimeout(ctx, f.config.RequestTimeout)
+		defer cancel()
+	}
+	q.FromBlock = new(big.Int).SetUint64(r.from)
+	q.ToBlock = new(big.Int).SetUint64(r.to)
+	logs, err := f.client.FilterLogs(reqCtx, q)
+	results <- logResult{r: r, logs: logs, err: err}
+}
+
+// isRangeTooLarge reports whether err means that a log query should be
+// retried over a smaller block range. Request timeouts count, whether
+// reported as is or wrapped, such as in the *url.Error of HTTP transports.
+func isRangeTooLarge(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	msg := strings.ToLower(err.Error())
+	if strings.Contains(msg, context.DeadlineExceeded.Error()) {
+		return true
+	}
+	for _, m := range rangeTooLargeMessages {
+		if strings.Contains(msg, m) {
+			return true
+		}
+	}
+	return false
+}
+
+type logRanges []logRange
+
+func (r logRanges) Len() int           { return len(r) }
+func (r logRanges) Less(i, j int) bool { return r[i].from < r[j].from }
+func (r logRanges) Swap(i, j int)      { r[i], r[j] = r[j], r[i] }
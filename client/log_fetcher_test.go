@@ -0,0 +1,143 @@
+// Copyright 2017 AMIS Technologies
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package client
+
+import (
+	"context"
+	"math/big"
+	"math/rand"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	ethrpc "github.com/ethereum/go-ethereum/rpc"
+)
+
+func TestLogFetcher(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	chain := &FakeChain{maxLogs: 50}
+	for block := uint64(0); block < 20000; block++ {
+		// Sparse logs with a few dense blocks in between.
+		n := 0
+		if rnd.Intn(20) == 0 {
+			n = 1
+		}
+		if block%5000 == 100 {
+			n = 40
+		}
+		for i := 0; i < n; i++ {
+			chain.logs = append(chain.logs, types.Log{
+				BlockNumber: block,
+				TxHash:      common.BigToHash(big.NewInt(int64(block))),
+				Index:       uint(i),
+				Topics:      []common.Hash{},
+			})
+		}
+	}
+	fetcher := NewLogFetcher(newTestNodeClient(t, chain), LogFetcherConfig{
+		InitialChunk: 8000,
+		Concurrency:  3,
+	})
+
+	q := ethereum.FilterQuery{FromBlock: big.NewInt(10), ToBlock: big.NewInt(19990)}
+	logs, err := fetcher.FilterLogs(context.Background(), q)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var want []types.Log
+	for _, l := range chain.logs {
+		if l.BlockNumber >= 10 && l.BlockNumber <= 19990 {
+			want = append(want, l)
+		}
+	}
+	if len(logs) != len(want) {
+		t.Fatalf("unexpected number of logs: have %d, want %d", len(logs), len(want))
+	}
+	for i := range want {
+		if logs[i].BlockNumber != want[i].BlockNumber || logs[i].Index != want[i].Index {
+			t.Fatalf("log %d out of order: have %d/%d, want %d/%d", i, logs[i].BlockNumber, logs[i].Index, want[i].BlockNumber, want[i].Index)
+		}
+	}
+
+	// A block with more logs than the node returns cannot be split further.
+	strict := NewLogFetcher(newTestNodeClient(t, &FakeChain{logs: chain.logs, maxLogs: 10}), LogFetcherConfig{})
+	if _, err := strict.FilterLogs(context.Background(), q); err == nil {
+		t.Error("expected error for oversized block")
+	}
+
+	// Abandoning the iteration stops the query.
+	it := fetcher.Iterator(context.Background(), q)
+	for i := 0; i < 5 && it.Next(); i++ {
+	}
+	it.Close()
+	if it.Next() {
+		t.Error("iterator not closed")
+	}
+}
+
+// SlowChain is a FakeChain which answers log queries over more than maxRange
+// blocks only after delay, as an overloaded node does.
+type SlowChain struct {
+	*FakeChain
+	maxRange uint64
+	delay    time.Duration
+}
+
+func (c *SlowChain) GetLogs(args map[string]interface{}) ([]types.Log, error) {
+	from, _ := hexutil.DecodeUint64(args["fromBlock"].(string))
+	to, _ := hexutil.DecodeUint64(args["toBlock"].(string))
+	if to-from+1 > c.maxRange {
+		time.Sleep(c.delay)
+	}
+	return c.FakeChain.GetLogs(args)
+}
+
+func TestLogFetcherTimeout(t *testing.T) {
+	chain := &FakeChain{}
+	for block := uint64(0); block < 1000; block += 10 {
+		chain.logs = append(chain.logs, types.Log{BlockNumber: block, Topics: []common.Hash{}})
+	}
+	srv := ethrpc.NewServer()
+	if err := srv.RegisterName("eth", &SlowChain{FakeChain: chain, maxRange: 250, delay: 200 * time.Millisecond}); err != nil {
+		t.Fatal(err)
+	}
+	// Over HTTP, timeouts are reported wrapped in a *url.Error.
+	server := httptest.NewServer(srv)
+	defer server.Close()
+	rpc, err := ethrpc.Dial(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := NewClient(rpc)
+	defer c.Close()
+
+	fetcher := NewLogFetcher(c, LogFetcherConfig{
+		InitialChunk:   1000,
+		RequestTimeout: 50 * time.Millisecond,
+	})
+	logs, err := fetcher.FilterLogs(context.Background(), ethereum.FilterQuery{FromBlock: big.NewInt(0), ToBlock: big.NewInt(999)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(logs) != len(chain.logs) {
+		t.Errorf("unexpected number of logs: have %d, want %d", len(logs), len(chain.logs))
+	}
+}
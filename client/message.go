@@ -0,0 +1,208 @@
+// Copyright 2017 AMIS Technologies
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package client
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+var (
+	// ErrInvalidSignature is returned if a signature is malformed or does not
+	// recover to a public key.
+	ErrInvalidSignature = errors.New("invalid signature")
+	// ErrSignatureMismatch is returned if a signature recovers to an address
+	// other than the expected one.
+	ErrSignatureMismatch = errors.New("signature does not match the expected address")
+)
+
+// MessageSigner signs EIP-191 personal messages on behalf of a single account.
+type MessageSigner interface {
+	// Address returns the account the signer signs for.
+	Address() common.Address
+	// SignMessage returns the 65 byte [R || S || V] signature of
+	// TextHash(msg), with V being 27 or 28.
+	SignMessage(ctx context.Context, msg []byte) ([]byte, error)
+}
+
+// TextHash returns the hash signed by personal_sign and eth_sign for msg,
+// keccak256("\x19Ethereum Signed Message:\n" + len(msg) + msg).
+func TextHash(msg []byte) common.Hash {
+	prefix := fmt.Sprintf("\x19Ethereum Signed Message:\n%d", len(msg))
+	return crypto.Keccak256Hash([]byte(prefix), msg)
+}
+
+// DataHash returns the EIP-191 version 0x00 hash of data intended for the
+// validator contract, keccak256(0x19 || 0x00 || validator || data).
+func DataHash(validator common.Address, data []byte) common.Hash {
+	return crypto.Keccak256Hash([]byte{0x19, 0x00}, validator.Bytes(), data)
+}
+
+// SignHash signs hash with key and returns the signature with V being 27 or
+// 28, as expected by ecrecover and most wallets.
+func SignHash(hash common.Hash, key *ecdsa.PrivateKey) ([]byte, error) {
+	sig, err := crypto.Sign(hash.Bytes(), key)
+	if err != nil {
+		return nil, err
+	}
+	sig[64] += 27
+	return sig, nil
+}
+
+// RecoverHash returns the address that signed hash. The V value of sig may
+// be 0/1, 27/28 or EIP-155 encoded.
+func RecoverHash(hash common.Hash, sig []byte) (common.Address, error) {
+	normalized, err := normalizeSignature(sig)
+	if err != nil {
+		return common.Address{}, err
+	}
+	pub, err := crypto.SigToPub(hash.Bytes(), normalized)
+	if err != nil || pub == nil || pub.X == nil {
+		return common.Address{}, ErrInvalidSignature
+	}
+	return crypto.PubkeyToAddress(*pub), nil
+}
+
+// RecoverMessage returns the address that signed the personal message msg.
+func RecoverMessage(msg, sig []byte) (common.Address, error) {
+	return RecoverHash(TextHash(msg), sig)
+}
+
+// VerifySignature checks that sig is a personal message signature of msg by
+// expected.
+func VerifySignature(msg, sig []byte, expected common.Address) error {
+	return verifyHash(TextHash(msg), sig, expected)
+}
+
+// VerifyData checks that sig is an EIP-191 version 0x00 signature of data
+// for validator by expected.
+func VerifyData(validator common.Address, data, sig []byte, expected common.Address) error {
+	return verifyHash(DataHash(validator, data), sig, expected)
+}
+
+func verifyHash(hash common.Hash, sig []byte, expected common.Address) error {
+	signer, err := RecoverHash(hash, sig)
+	if err != nil {
+		return err
+	}
+	if signer != expected {
+		return ErrSignatureMismatch
+	}
+	return nil
+}
+
+// normalizeSignature returns a copy of sig with V being 0 or 1, as expected
+// by the crypto package, and rejects malleable signatures.
+func normalizeSignature(sig []byte) ([]byte, error) {
+	if len(sig) != 65 {
+		return nil, ErrInvalidSignature
+	}
+	out := common.CopyBytes(sig)
+	switch v := out[64]; {
+	case v == 0 || v == 1:
+	case v == 27 || v == 28:
+		out[64] = v - 27
+	case v >= 35:
+		// EIP-155 style, chainID*2 + 35 + recovery id
+		out[64] = (v - 35) % 2
+	default:
+		return nil, ErrInvalidSignature
+	}
+	r, s := new(big.Int).SetBytes(out[:32]), new(big.Int).SetBytes(out[32:64])
+	if !crypto.ValidateSignatureValues(out[64], r, s, true) {
+		return nil, ErrInvalidSignature
+	}
+	return out, nil
+}
+
+// KeySigner signs transactions and messages with a local private key.
+type KeySigner struct {
+	key     *ecdsa.PrivateKey
+	address common.Address
+}
+
+// NewKeySigner creates a signer for the account of key.
+func NewKeySigner(key *ecdsa.PrivateKey) *KeySigner {
+	return &KeySigner{
+		key:     key,
+		address: crypto.PubkeyToAddress(key.PublicKey),
+	}
+}
+
+// Address returns the account the signer signs for.
+func (s *KeySigner) Address() common.Address {
+	return s.address
+}
+
+// SignTx signs tx for the given chain with EIP-155 replay protection.
+func (s *KeySigner) SignTx(ctx context.Context, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	return types.SignTx(tx, types.NewEIP155Signer(chainID), s.key)
+}
+
+// SignMessage signs the personal message msg.
+func (s *KeySigner) SignMessage(ctx context.Context, msg []byte) ([]byte, error) {
+	return SignHash(TextHash(msg), s.key)
+}
+
+// SignData signs data for validator following EIP-191 version 0x00.
+func (s *KeySigner) SignData(ctx context.Context, validator common.Address, data []byte) ([]byte, error) {
+	return SignHash(DataHash(validator, data), s.key)
+}
+
+// NodeSigner signs messages with an account held by the node, using eth_sign.
+type NodeSigner struct {
+	client  Client
+	account common.Address
+}
+
+// NewNodeSigner creates a signer for an account unlocked on the node.
+func NewNodeSigner(client Client, account common.Address) *NodeSigner {
+	return &NodeSigner{
+		client:  client,
+		account: account,
+	}
+}
+
+// Address returns the account the signer signs for.
+func (s *NodeSigner) Address() common.Address {
+	return s.account
+}
+
+// SignMessage signs the personal message msg on the node. The signature is
+// checked to recover to the account and returned with V being 27 or 28.
+func (s *NodeSigner) SignMessage(ctx context.Context, msg []byte) ([]byte, error) {
+	sig, err := s.client.Sign(ctx, s.account, msg)
+	if err != nil {
+		return nil, err
+	}
+	normalized, err := normalizeSignature(sig)
+	if err != nil {
+		return nil, err
+	}
+	if err := VerifySignature(msg, normalized, s.account); err != nil {
+		return nil, err
+	}
+	normalized[64] += 27
+	return normalized, nil
+}
@@ -0,0 +1,105 @@
+// Copyright 2017 AMIS Technologies
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package client
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Verify that the signers implement the signer interfaces.
+var (
+	_ = Signer(&KeySigner{})
+	_ = MessageSigner(&KeySigner{})
+	_ = MessageSigner(&NodeSigner{})
+)
+
+func TestMessageSignature(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	other, _ := crypto.GenerateKey()
+	signer := NewKeySigner(key)
+	msg := []byte("login challenge 42")
+	ctx := context.Background()
+
+	sig, err := signer.SignMessage(ctx, msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sig[64] != 27 && sig[64] != 28 {
+		t.Fatalf("unexpected v: %d", sig[64])
+	}
+
+	withV := func(v byte) []byte {
+		out := common.CopyBytes(sig)
+		out[64] = v
+		return out
+	}
+	tests := []struct {
+		msg      []byte
+		sig      []byte
+		expected common.Address
+		err      error
+	}{
+		{msg, sig, signer.Address(), nil},
+		{msg, withV(sig[64] - 27), signer.Address(), nil},
+		{msg, withV(sig[64] - 27 + 37), signer.Address(), nil},
+		{msg, sig, crypto.PubkeyToAddress(other.PublicKey), ErrSignatureMismatch},
+		{[]byte("other challenge"), sig, signer.Address(), ErrSignatureMismatch},
+		{msg, sig[:64], signer.Address(), ErrInvalidSignature},
+		{msg, withV(5), signer.Address(), ErrInvalidSignature},
+	}
+	for i, test := range tests {
+		if err := VerifySignature(test.msg, test.sig, test.expected); err != test.err {
+			t.Errorf("test %d: have %v, want %v", i, err, test.err)
+		}
+	}
+
+	validator := common.HexToAddress("0x00000000000000000000000000000000000000aa")
+	sig, err = signer.SignData(ctx, validator, msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifyData(validator, msg, sig, signer.Address()); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := VerifyData(common.Address{}, msg, sig, signer.Address()); err != ErrSignatureMismatch {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestNodeSigner(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	client := newTestNodeClient(t, &FakeAccount{key: key})
+	msg := []byte("login challenge 42")
+
+	signer := NewNodeSigner(client, crypto.PubkeyToAddress(key.PublicKey))
+	sig, err := signer.SignMessage(context.Background(), msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifySignature(msg, sig, signer.Address()); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	signer = NewNodeSigner(client, common.HexToAddress("0x00000000000000000000000000000000000000aa"))
+	if _, err := signer.SignMessage(context.Background(), msg); err == nil {
+		t.Error("expected error for unknown account")
+	}
+}
@@ -0,0 +1,136 @@
+// Copyright 2017 AMIS Technologies
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package client
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	ethrpc "github.com/ethereum/go-ethereum/rpc"
+)
+
+// DefaultMulticallChunkSize is the default number of calls sent in a single
+// JSON-RPC batch.
+const DefaultMulticallChunkSize = 100
+
+// Call is a single call of an aggregated call. If Method is set, the output
+// of the call is decoded according to its outputs.
+type Call struct {
+	Msg    ethereum.CallMsg
+	Method *abi.Method
+}
+
+// CallResult is the outcome of a single call of an aggregated call. Values is
+// only set for calls with a method.
+type CallResult struct {
+	Output []byte
+	Values []interface{}
+	Err    error
+}
+
+// Multicaller runs many calls against the same block using JSON-RPC
+// batches, so that their results form a consistent snapshot.
+type Multicaller struct {
+	client    Client
+	chunkSize int
+}
+
+// NewMulticaller creates a multicaller which sends at most chunkSize calls in
+// a single batch. A non-positive chunkSize uses DefaultMulticallChunkSize.
+func NewMulticaller(client Client, chunkSize int) *Multicaller {
+	if chunkSize <= 0 {
+		chunkSize = DefaultMulticallChunkSize
+	}
+	return &Multicaller{
+		client:    client,
+		chunkSize: chunkSize,
+	}
+}
+
+// Call runs all calls at the given block and returns their results in the
+// same order. A nil blockNumber is resolved to the latest block number
+// first, which is returned, so that all chunks see the same state. Errors of
+// single calls are reported in their results, reverts as a *RevertError and
+// other failures classified with ClassifyError.
+func (m *Multicaller) Call(ctx context.Context, blockNumber *big.Int, calls []Call) (*big.Int, []*CallResult, error) {
+	if blockNumber == nil {
+		var err error
+		if blockNumber, err = m.client.BlockNumber(ctx); err != nil {
+			return nil, nil, err
+		}
+	}
+	block := hexutil.EncodeBig(blockNumber)
+
+	results := make([]*CallResult, len(calls))
+	for start := 0; start < len(calls); start += m.chunkSize {
+		end := start + m.chunkSize
+		if end > len(calls) {
+			end = len(calls)
+		}
+		outputs := make([]hexutil.Bytes, end-start)
+		batch := make([]ethrpc.BatchElem, end-start)
+		for i := range batch {
+			batch[i] = ethrpc.BatchElem{
+				Method: "eth_call",
+				Args:   []interface{}{toCallArg(calls[start+i].Msg), block},
+				Result: &outputs[i],
+			}
+		}
+		if err := m.client.BatchCallContext(ctx, batch); err != nil {
+			return nil, nil, err
+		}
+		for i, elem := range batch {
+			results[start+i] = newCallResult(calls[start+i], outputs[i], elem.Error)
+		}
+	}
+	return blockNumber, results, nil
+}
+
+func newCallResult(call Call, output []byte, err error) *CallResult {
+	if err != nil {
+		return &CallResult{Err: decodeCallError(err)}
+	}
+	result := &CallResult{Output: output}
+	if call.Method != nil {
+		result.Values, result.Err = unpackArguments(call.Method.Outputs, output)
+	}
+	return result
+}
+
+// toCallArg converts a call message into eth_call arguments.
+func toCallArg(msg ethereum.CallMsg) interface{} {
+	arg := map[string]interface{}{
+		"from": msg.From,
+		"to":   msg.To,
+	}
+	if len(msg.Data) > 0 {
+		arg["data"] = hexutil.Bytes(msg.Data)
+	}
+	if msg.Value != nil {
+		arg["value"] = (*hexutil.Big)(msg.Value)
+	}
+	if msg.Gas != nil {
+		arg["gas"] = (*hexutil.Big)(msg.Gas)
+	}
+	if msg.GasPrice != nil {
+		arg["gasPrice"] = (*hexutil.Big)(msg.GasPrice)
+	}
+	return arg
+}
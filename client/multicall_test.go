@@ -0,0 +1,66 @@
+// Copyright 2017 AMIS Technologies
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package client
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+func TestMulticaller(t *testing.T) {
+	state := &FakeState{head: 100, failures: map[string]string{hexutil.Encode([]byte("four")): "intrinsic gas too low"}}
+	c := newTestNodeClient(t, state)
+	defer c.Close()
+
+	to := common.HexToAddress("0x1234")
+	inputs := []string{"one", "revert", "three", "four"}
+	calls := make([]Call, len(inputs))
+	for i, input := range inputs {
+		calls[i] = Call{Msg: ethereum.CallMsg{To: &to, Data: []byte(input)}}
+	}
+
+	block, results, err := NewMulticaller(c, 2).Call(context.Background(), nil, calls)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if block.Int64() != 100 {
+		t.Errorf("block mismatch: have %v, want 100", block)
+	}
+	if len(state.callBlocks) != len(calls) {
+		t.Fatalf("call count mismatch: have %d, want %d", len(state.callBlocks), len(calls))
+	}
+	for _, b := range state.callBlocks {
+		if b != "0x64" {
+			t.Errorf("call not pinned to block 0x64: %s", b)
+		}
+	}
+	if !bytes.Equal(results[0].Output, []byte("one")) || !bytes.Equal(results[2].Output, []byte("three")) {
+		t.Errorf("output mismatch: have %q, %q", results[0].Output, results[2].Output)
+	}
+	if err, ok := results[1].Err.(*RevertError); !ok || err.Reason != "reverted" {
+		t.Errorf("error mismatch: have %v, want revert", results[1].Err)
+	}
+	if !errors.Is(results[3].Err, ErrIntrinsicGas) {
+		t.Errorf("error mismatch: have %v, want %v", results[3].Err, ErrIntrinsicGas)
+	}
+}
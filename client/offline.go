@@ -0,0 +1,265 @@
+// Copyright 2017 AMIS Technologies
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+var (
+	// ErrChainIDMismatch is returned if a signed transaction is not replay
+	// protected for the expected chain.
+	ErrChainIDMismatch = errors.New("transaction chain id mismatch")
+	// ErrEnvelopeMismatch is returned if the fields of an offline transaction
+	// envelope do not match its RLP encoding.
+	ErrEnvelopeMismatch = errors.New("offline transaction fields do not match rlp")
+)
+
+// OfflineTx is a portable envelope of an unsigned transaction, to be carried
+// to an air-gapped machine for signing. The transaction is included both as
+// readable fields for review and as RLP, and both must agree.
+type OfflineTx struct {
+	ChainID *hexutil.Big   `json:"chainId"`
+	From    common.Address `json:"from"`
+	// Private marks a Quorum private transaction, whose data is the hash of
+	// the payload stored in the transaction manager.
+	Private  bool            `json:"private,omitempty"`
+	Nonce    hexutil.Uint64  `json:"nonce"`
+	To       *common.Address `json:"to"`
+	Value    *hexutil.Big    `json:"value"`
+	Gas      *hexutil.Big    `json:"gas"`
+	GasPrice *hexutil.Big    `json:"gasPrice"`
+	Data     hexutil.Bytes   `json:"data"`
+	RLP      hexutil.Bytes   `json:"rlp"`
+}
+
+// NewOfflineTx creates an envelope for the unsigned tx to be signed by from
+// for the given chain.
+func NewOfflineTx(tx *types.Transaction, from common.Address, chainID *big.Int, private bool) (*OfflineTx, error) {
+	encoded, err := rlp.EncodeToBytes(tx)
+	if err != nil {
+		return nil, err
+	}
+	return &OfflineTx{
+		ChainID:  (*hexutil.Big)(chainID),
+		From:     from,
+		Private:  private,
+		Nonce:    hexutil.Uint64(tx.Nonce()),
+		To:       tx.To(),
+		Value:    (*hexutil.Big)(tx.Value()),
+		Gas:      (*hexutil.Big)(tx.Gas()),
+		GasPrice: (*hexutil.Big)(tx.GasPrice()),
+		Data:     tx.Data(),
+		RLP:      encoded,
+	}, nil
+}
+
+// Transaction decodes the unsigned transaction of the envelope and checks
+// that it matches the readable fields.
+func (o *OfflineTx) Transaction() (*types.Transaction, error) {
+	tx := new(types.Transaction)
+	if err := rlp.DecodeBytes(o.RLP, tx); err != nil {
+		return nil, err
+	}
+	sameTo := (tx.To() == nil && o.To == nil) || (tx.To() != nil && o.To != nil && *tx.To() == *o.To)
+	if !sameTo || tx.Nonce() != uint64(o.Nonce) ||
+		!equalBig(tx.Value(), o.Value) || !equalBig(tx.Gas(), o.Gas) || !equalBig(tx.GasPrice(), o.GasPrice) ||
+		!bytes.Equal(tx.Data(), o.Data) {
+		return nil, ErrEnvelopeMismatch
+	}
+	return tx, nil
+}
+
+// Sign signs the envelope with key, which must belong to the From account,
+// and returns the raw signed transaction. Public transactions are signed
+// with EIP-155 replay protection, private ones as Quorum expects.
+func (o *OfflineTx) Sign(key *ecdsa.PrivateKey) ([]byte, error) {
+	if crypto.PubkeyToAddress(key.PublicKey) != o.From {
+		return nil, ErrSenderMismatch
+	}
+	tx, err := o.Transaction()
+	if err != nil {
+		return nil, err
+	}
+	var signer types.Signer = PrivateTxSigner{}
+	if !o.Private {
+		if o.ChainID == nil {
+			return nil, errors.New("missing chain id")
+		}
+		signer = types.NewEIP155Signer(o.ChainID.ToInt())
+	}
+	signed, err := types.SignTx(tx, signer, key)
+	if err != nil {
+		return nil, err
+	}
+	return rlp.EncodeToBytes(signed)
+}
+
+// RawTx is a decoded signed transaction.
+type RawTx struct {
+	Tx   *types.Transaction
+	From common.Address
+	// ChainID is nil for transactions without replay protection.
+	ChainID *big.Int
+	// Private is set for Quorum private transactions.
+	Private bool
+}
+
+// DecodeRawTx decodes a hex encoded signed transaction of the chain with the
+// given ID and recovers its sender. Quorum private transactions are only
+// recognized if chainID is set to a chain other than 1, whose EIP-155
+// signatures use the same V values.
+func DecodeRawTx(rawHex string, chainID *big.Int) (*RawTx, error) {
+	raw, err := hexutil.Decode(rawHex)
+	if err != nil {
+		return nil, err
+	}
+	tx := new(types.Transaction)
+	if err := rlp.DecodeBytes(raw, tx); err != nil {
+		return nil, err
+	}
+	from, err := txSender(tx, chainID)
+	if err != nil {
+		return nil, err
+	}
+	result := &RawTx{
+		Tx:      tx,
+		From:    from,
+		Private: isPrivateTx(tx, chainID),
+	}
+	if tx.Protected() && !result.Private {
+		result.ChainID = tx.ChainId()
+	}
+	return result, nil
+}
+
+// Validate checks that the transaction was signed by from for the given
+// chain and pays at least its intrinsic gas. Quorum private transactions are
+// not replay protected and skip the chain check.
+func (r *RawTx) Validate(chainID *big.Int, from common.Address) error {
+	if r.From != from {
+		return ErrSenderMismatch
+	}
+	if !r.Private && (r.ChainID == nil || r.ChainID.Cmp(chainID) != 0) {
+		return ErrChainIDMismatch
+	}
+	if r.Tx.Gas().Cmp(new(big.Int).SetUint64(IntrinsicGas(r.Tx.Data(), r.Tx.To() == nil))) < 0 {
+		return ErrIntrinsicGas
+	}
+	return nil
+}
+
+// BroadcastRawTx decodes and validates a hex encoded signed transaction and
+// sends it through client.
+func BroadcastRawTx(ctx context.Context, client Client, rawHex string, chainID *big.Int, from common.Address) (common.Hash, error) {
+	raw, err := DecodeRawTx(rawHex, chainID)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	if err := raw.Validate(chainID, from); err != nil {
+		return common.Hash{}, err
+	}
+	if err := client.SendRawTransaction(ctx, raw.Tx); err != nil {
+		return common.Hash{}, err
+	}
+	return raw.Tx.Hash(), nil
+}
+
+// IntrinsicGas returns the gas a transaction with the given data pays before
+// any execution, following the Homestead rules.
+func IntrinsicGas(data []byte, creation bool) uint64 {
+	gas := params.TxGas
+	if creation {
+		gas = params.TxGasContractCreation
+	}
+	for _, b := range data {
+		if b == 0 {
+			gas += params.TxDataZeroGas
+		} else {
+			gas += params.TxDataNonZeroGas
+		}
+	}
+	return gas
+}
+
+// PrivateTxSigner signs Quorum private transactions, which use the
+// Homestead signing hash with V set to 37 or 38.
+type PrivateTxSigner struct {
+	types.HomesteadSigner
+}
+
+// Equal returns true if the given signer is a PrivateTxSigner.
+func (s PrivateTxSigner) Equal(s2 types.Signer) bool {
+	_, ok := s2.(PrivateTxSigner)
+	return ok
+}
+
+// SignatureValues returns the raw R, S, V values of sig with V set to 37 or
+// 38.
+func (s PrivateTxSigner) SignatureValues(tx *types.Transaction, sig []byte) (r, ss, v *big.Int, err error) {
+	r, ss, v, err = s.HomesteadSigner.SignatureValues(tx, sig)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return r, ss, v.Add(v, big.NewInt(10)), nil
+}
+
+// Sender returns the sender of a private transaction.
+func (s PrivateTxSigner) Sender(tx *types.Transaction) (common.Address, error) {
+	if !hasPrivateV(tx) {
+		return common.Address{}, ErrInvalidSignature
+	}
+	v, r, ss := tx.RawSignatureValues()
+	sig := make([]byte, 65)
+	copy(sig[32-len(r.Bytes()):32], r.Bytes())
+	copy(sig[64-len(ss.Bytes()):64], ss.Bytes())
+	sig[64] = byte(v.Uint64() - 37)
+	return RecoverHash(s.Hash(tx), sig)
+}
+
+// isPrivateTx reports whether tx is a signed Quorum private transaction of
+// the chain with the given ID. Private transactions have V set to 37 or 38,
+// like EIP-155 transactions of chain 1, so they are only recognized for a
+// known chain ID other than 1. Quorum does not allow chain ID 1 for this
+// reason.
+func isPrivateTx(tx *types.Transaction, chainID *big.Int) bool {
+	return chainID != nil && chainID.Cmp(common.Big1) != 0 && hasPrivateV(tx)
+}
+
+// hasPrivateV reports whether the V value of tx is 37 or 38.
+func hasPrivateV(tx *types.Transaction) bool {
+	v, _, _ := tx.RawSignatureValues()
+	return v != nil && v.BitLen() <= 8 && (v.Uint64() == 37 || v.Uint64() == 38)
+}
+
+func equalBig(a *big.Int, b *hexutil.Big) bool {
+	if b == nil {
+		return a == nil || a.Sign() == 0
+	}
+	return a.Cmp(b.ToInt()) == 0
+}
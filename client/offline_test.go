@@ -0,0 +1,159 @@
+// Copyright 2017 AMIS Technologies
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func TestOfflineTx(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	from := crypto.PubkeyToAddress(key.PublicKey)
+	chainID := big.NewInt(2017)
+	to := common.HexToAddress("0x00000000000000000000000000000000000000aa")
+
+	for _, private := range []bool{false, true} {
+		tx := types.NewTransaction(3, to, big.NewInt(1), big.NewInt(21000+68*2), big.NewInt(0), []byte{1, 2})
+		envelope, err := NewOfflineTx(tx, from, chainID, private)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Carry the envelope over as JSON.
+		blob, err := json.Marshal(envelope)
+		if err != nil {
+			t.Fatal(err)
+		}
+		received := new(OfflineTx)
+		if err := json.Unmarshal(blob, received); err != nil {
+			t.Fatal(err)
+		}
+
+		raw, err := received.Sign(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		decoded, err := DecodeRawTx(hexutil.Encode(raw), chainID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if decoded.From != from || decoded.Private != private {
+			t.Errorf("unexpected decoded tx: %+v", decoded)
+		}
+		if v, _, _ := decoded.Tx.RawSignatureValues(); private && v.Uint64() != 37 && v.Uint64() != 38 {
+			t.Errorf("unexpected private v: %v", v)
+		}
+		if err := decoded.Validate(chainID, from); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		if err := decoded.Validate(chainID, to); err != ErrSenderMismatch {
+			t.Errorf("unexpected error: %v", err)
+		}
+		if err := decoded.Validate(big.NewInt(1), from); !private && err != ErrChainIDMismatch {
+			t.Errorf("unexpected error: %v", err)
+		}
+	}
+}
+
+func TestDecodeChainOneTx(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	from := crypto.PubkeyToAddress(key.PublicKey)
+	to := common.HexToAddress("0x00000000000000000000000000000000000000aa")
+
+	// EIP-155 signatures of chain 1 have V 37 or 38, like Quorum private
+	// transactions.
+	tx := types.NewTransaction(0, to, big.NewInt(0), big.NewInt(21000), big.NewInt(0), nil)
+	envelope, _ := NewOfflineTx(tx, from, big.NewInt(1), false)
+	raw, err := envelope.Sign(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, chainID := range []*big.Int{nil, big.NewInt(1)} {
+		decoded, err := DecodeRawTx(hexutil.Encode(raw), chainID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if decoded.Private || decoded.From != from || decoded.ChainID == nil || decoded.ChainID.Int64() != 1 {
+			t.Errorf("chain id %v: unexpected decoded tx: %+v", chainID, decoded)
+		}
+		if err := decoded.Validate(big.NewInt(1), from); err != nil {
+			t.Errorf("chain id %v: unexpected error: %v", chainID, err)
+		}
+	}
+
+	// On other chains the same V values mark private transactions.
+	decoded, err := DecodeRawTx(hexutil.Encode(raw), big.NewInt(2017))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !decoded.Private || decoded.From == from {
+		t.Errorf("unexpected decoded tx: %+v", decoded)
+	}
+}
+
+func TestOfflineTxValidation(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	from := crypto.PubkeyToAddress(key.PublicKey)
+	chainID := big.NewInt(2017)
+	to := common.HexToAddress("0x00000000000000000000000000000000000000aa")
+
+	tx := types.NewTransaction(0, to, big.NewInt(0), big.NewInt(21000), big.NewInt(0), []byte{1})
+	envelope, _ := NewOfflineTx(tx, from, chainID, false)
+
+	other, _ := crypto.GenerateKey()
+	if _, err := envelope.Sign(other); err != ErrSenderMismatch {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	tampered := *envelope
+	tampered.To = &from
+	if _, err := tampered.Sign(key); err != ErrEnvelopeMismatch {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	raw, err := envelope.Sign(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool := &FakeTxPool{}
+	if _, err := BroadcastRawTx(context.Background(), newTestNodeClient(t, pool), hexutil.Encode(raw), chainID, from); !errors.Is(err, ErrIntrinsicGas) {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if len(pool.sent) != 0 {
+		t.Errorf("invalid transaction was broadcast")
+	}
+
+	tx = types.NewTransaction(0, to, big.NewInt(0), big.NewInt(21068), big.NewInt(0), []byte{1})
+	envelope, _ = NewOfflineTx(tx, from, chainID, false)
+	raw, _ = envelope.Sign(key)
+	hash, err := BroadcastRawTx(context.Background(), newTestNodeClient(t, pool), hexutil.Encode(raw), chainID, from)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pool.sent) != 1 || pool.sent[0] != hash {
+		t.Errorf("unexpected broadcast: %x", pool.sent)
+	}
+}
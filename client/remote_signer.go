@@ -0,0 +1,162 @@
+// Copyright 2017 AMIS Technologies
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// DefaultRemoteSignerTimeout bounds a request to the signing service when no
+// HTTP client is given.
+const DefaultRemoteSignerTimeout = 30 * time.Second
+
+// RemoteSigner delegates transaction signing to an external signing service
+// over JSON-RPC on HTTP, so that private keys never live in the application
+// process. The service is expected to implement eth_signTransaction as geth
+// does and to return the RLP encoded signed transaction.
+type RemoteSigner struct {
+	url     string
+	auth    string
+	account common.Address
+	client  *http.Client
+	id      uint64
+}
+
+// NewRemoteSigner creates a signer for account backed by the signing service
+// at url. If auth is not empty, it is sent as the Authorization header of
+// every request. Requests time out after DefaultRemoteSignerTimeout.
+func NewRemoteSigner(url string, auth string, account common.Address) *RemoteSigner {
+	return NewRemoteSignerWithClient(&http.Client{Timeout: DefaultRemoteSignerTimeout}, url, auth, account)
+}
+
+// NewRemoteSignerWithClient is like NewRemoteSigner but sends the requests
+// through the given HTTP client, e.g. to set a timeout or TLS settings.
+func NewRemoteSignerWithClient(client *http.Client, url string, auth string, account common.Address) *RemoteSigner {
+	return &RemoteSigner{
+		url:     url,
+		auth:    auth,
+		account: account,
+		client:  client,
+	}
+}
+
+// Address returns the account the signer signs for.
+func (s *RemoteSigner) Address() common.Address {
+	return s.account
+}
+
+// SignTx sends the unsigned transaction to the signing service and decodes
+// the signed transaction it returns.
+func (s *RemoteSigner) SignTx(ctx context.Context, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	nonce := hexutil.Uint64(tx.Nonce())
+	args := map[string]interface{}{
+		"from":     s.account,
+		"to":       tx.To(),
+		"gas":      (*hexutil.Big)(tx.Gas()),
+		"gasPrice": (*hexutil.Big)(tx.GasPrice()),
+		"value":    (*hexutil.Big)(tx.Value()),
+		"data":     hexutil.Bytes(tx.Data()),
+		"nonce":    &nonce,
+		"chainId":  (*hexutil.Big)(chainID),
+	}
+	var result struct {
+		Raw hexutil.Bytes `json:"raw"`
+	}
+	if err := s.call(ctx, &result, "eth_signTransaction", args); err != nil {
+		return nil, err
+	}
+	signed := new(types.Transaction)
+	if err := rlp.DecodeBytes(result.Raw, signed); err != nil {
+		return nil, err
+	}
+	return signed, nil
+}
+
+type remoteRequest struct {
+	Version string        `json:"jsonrpc"`
+	ID      uint64        `json:"id"`
+	Method  string        `json:"method"`
+	Params  []interface{} `json:"params"`
+}
+
+type remoteResponse struct {
+	Result json.RawMessage `json:"result"`
+	Error  *remoteError    `json:"error"`
+}
+
+type remoteError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (err *remoteError) Error() string {
+	return fmt.Sprintf("remote signer error %d: %s", err.Code, err.Message)
+}
+
+func (err *remoteError) ErrorCode() int {
+	return err.Code
+}
+
+func (s *RemoteSigner) call(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+	body, err := json.Marshal(&remoteRequest{
+		Version: "2.0",
+		ID:      atomic.AddUint64(&s.id, 1),
+		Method:  method,
+		Params:  args,
+	})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest("POST", s.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+	if s.auth != "" {
+		req.Header.Set("Authorization", s.auth)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("remote signer: %s", resp.Status)
+	}
+
+	var msg remoteResponse
+	if err := json.NewDecoder(resp.Body).Decode(&msg); err != nil {
+		return err
+	}
+	if msg.Error != nil {
+		return msg.Error
+	}
+	return json.Unmarshal(msg.Result, result)
+}
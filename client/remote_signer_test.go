@@ -0,0 +1,138 @@
+// Copyright 2017 AMIS Technologies
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package client
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+	ethrpc "github.com/ethereum/go-ethereum/rpc"
+)
+
+const testAuth = "Bearer secret"
+
+// FakeSigner is a stand-in for an external signing service.
+type FakeSigner struct {
+	key *ecdsa.PrivateKey
+}
+
+type FakeSignArgs struct {
+	To       *common.Address `json:"to"`
+	Gas      *hexutil.Big    `json:"gas"`
+	GasPrice *hexutil.Big    `json:"gasPrice"`
+	Value    *hexutil.Big    `json:"value"`
+	Data     hexutil.Bytes   `json:"data"`
+	Nonce    hexutil.Uint64  `json:"nonce"`
+	ChainID  *hexutil.Big    `json:"chainId"`
+}
+
+func (s *FakeSigner) SignTransaction(args FakeSignArgs) (map[string]interface{}, error) {
+	var tx *types.Transaction
+	if args.To == nil {
+		tx = types.NewContractCreation(uint64(args.Nonce), args.Value.ToInt(), args.Gas.ToInt(), args.GasPrice.ToInt(), args.Data)
+	} else {
+		tx = types.NewTransaction(uint64(args.Nonce), *args.To, args.Value.ToInt(), args.Gas.ToInt(), args.GasPrice.ToInt(), args.Data)
+	}
+	signed, err := types.SignTx(tx, types.NewEIP155Signer(args.ChainID.ToInt()), s.key)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := rlp.EncodeToBytes(signed)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"raw": hexutil.Bytes(raw)}, nil
+}
+
+func newTestSignerServer(t *testing.T, key *ecdsa.PrivateKey) *httptest.Server {
+	srv := ethrpc.NewServer()
+	if err := srv.RegisterName("eth", &FakeSigner{key: key}); err != nil {
+		t.Fatal(err)
+	}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != testAuth {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		srv.ServeHTTP(w, r)
+	}))
+}
+
+func TestRemoteSigner(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	server := newTestSignerServer(t, key)
+	defer server.Close()
+
+	pool := &FakeTxPool{}
+	c := newTestNodeClient(t, pool)
+	defer c.Close()
+
+	chainID := big.NewInt(2017)
+	to := common.HexToAddress("0x1234")
+	tx := types.NewTransaction(3, to, big.NewInt(1), big.NewInt(21000), big.NewInt(1), nil)
+
+	signer := NewRemoteSigner(server.URL, testAuth, crypto.PubkeyToAddress(key.PublicKey))
+	signed, err := NewTransactor(c, signer, chainID).Send(context.Background(), tx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(pool.sent) != 1 || pool.sent[0] != signed.Hash() {
+		t.Errorf("broadcast mismatch: have %v, want %v", pool.sent, signed.Hash())
+	}
+
+	// A signer answering with another key must be rejected.
+	other, _ := crypto.GenerateKey()
+	signer = NewRemoteSigner(server.URL, testAuth, crypto.PubkeyToAddress(other.PublicKey))
+	if _, err := NewTransactor(c, signer, chainID).Send(context.Background(), tx); err != ErrSenderMismatch {
+		t.Errorf("error mismatch: have %v, want %v", err, ErrSenderMismatch)
+	}
+
+	// Requests without credentials must fail.
+	signer = NewRemoteSigner(server.URL, "", crypto.PubkeyToAddress(key.PublicKey))
+	if _, err := signer.SignTx(context.Background(), tx, chainID); err == nil {
+		t.Error("expected an error for unauthorized request")
+	}
+	if len(pool.sent) != 1 {
+		t.Errorf("unexpected broadcast count: have %d, want 1", len(pool.sent))
+	}
+}
+
+func TestRemoteSignerTimeout(t *testing.T) {
+	done := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-done
+	}))
+	defer server.Close()
+	defer close(done)
+
+	client := &http.Client{Timeout: 50 * time.Millisecond}
+	signer := NewRemoteSignerWithClient(client, server.URL, testAuth, common.HexToAddress("0x1234"))
+	tx := types.NewTransaction(0, common.HexToAddress("0x1234"), big.NewInt(1), big.NewInt(21000), big.NewInt(1), nil)
+	if _, err := signer.SignTx(context.Background(), tx, big.NewInt(2017)); err == nil {
+		t.Error("expected an error for a hanging signer")
+	}
+}
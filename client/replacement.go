@@ -0,0 +1,177 @@
+// Copyright 2017 AMIS Technologies
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package client
+
+import (
+	"context"
+	"errors"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// DefaultPriceBump is the minimum gas price bump percentage the geth txpool
+// requires to replace a pending transaction with the same nonce.
+const DefaultPriceBump = 10
+
+// ErrAlreadyMined is returned if a transaction to be replaced has already been
+// mined.
+var ErrAlreadyMined = errors.New("transaction already mined")
+
+// MinReplacementGasPrice returns the lowest gas price a transaction must pay
+// to replace a pending one paying price, given the txpool price bump in
+// percent. The txpool accepts replacements paying at least the bumped price
+// and more than the old one, which matters for prices of a few wei.
+func MinReplacementGasPrice(price *big.Int, bump uint64) *big.Int {
+	threshold := new(big.Int).Mul(price, new(big.Int).SetUint64(100+bump))
+	threshold.Div(threshold, big.NewInt(100))
+	if threshold.Cmp(price) <= 0 {
+		return new(big.Int).Add(price, common.Big1)
+	}
+	return threshold
+}
+
+// Replacement tracks a pending transaction together with the transactions
+// sent to replace it, until one of them is mined. All of them share the same
+// nonce, so at most one can be mined.
+type Replacement struct {
+	transactor *Transactor
+	txs        []*types.Transaction
+}
+
+// Transactions returns the tracked transactions, the original one first.
+func (r *Replacement) Transactions() []*types.Transaction {
+	return r.txs
+}
+
+// Hashes returns the hashes of the tracked transactions, the original one
+// first.
+func (r *Replacement) Hashes() []common.Hash {
+	hashes := make([]common.Hash, len(r.txs))
+	for i, tx := range r.txs {
+		hashes[i] = tx.Hash()
+	}
+	return hashes
+}
+
+// Latest returns the most recently sent transaction.
+func (r *Replacement) Latest() *types.Transaction {
+	return r.txs[len(r.txs)-1]
+}
+
+// Wait polls the tracked transactions until one of them is mined, and
+// returns it together with its receipt.
+func (r *Replacement) Wait(ctx context.Context) (*types.Transaction, *types.Receipt, error) {
+	i, receipt, err := waitAnyMined(ctx, r.transactor.client, r.Hashes())
+	if err != nil {
+		return nil, nil, err
+	}
+	return r.txs[i], receipt, nil
+}
+
+// SpeedUp re-sends the latest transaction with the same nonce and a higher
+// gas price. If gasPrice is nil, the larger of the minimum replacement price
+// and the node's suggestion is used.
+func (r *Replacement) SpeedUp(ctx context.Context, gasPrice *big.Int) error {
+	latest := r.Latest()
+	price, err := r.gasPrice(ctx, gasPrice)
+	if err != nil {
+		return err
+	}
+	if latest.To() == nil {
+		return r.send(ctx, types.NewContractCreation(latest.Nonce(), latest.Value(), latest.Gas(), price, latest.Data()))
+	}
+	return r.send(ctx, types.NewTransaction(latest.Nonce(), *latest.To(), latest.Value(), latest.Gas(), price, latest.Data()))
+}
+
+// Cancel replaces the latest transaction with a zero-value transfer to the
+// sender itself, using the same nonce and a higher gas price. If gasPrice is
+// nil, the larger of the minimum replacement price and the node's suggestion
+// is used.
+func (r *Replacement) Cancel(ctx context.Context, gasPrice *big.Int) error {
+	price, err := r.gasPrice(ctx, gasPrice)
+	if err != nil {
+		return err
+	}
+	return r.send(ctx, types.NewTransaction(r.Latest().Nonce(), r.transactor.Address(), new(big.Int), big.NewInt(21000), price, nil))
+}
+
+// gasPrice returns the gas price for the next replacement, or
+// ErrAlreadyMined if any tracked transaction has been mined.
+func (r *Replacement) gasPrice(ctx context.Context, gasPrice *big.Int) (*big.Int, error) {
+	client := r.transactor.client
+	for _, tx := range r.txs {
+		_, isPending, err := client.TransactionByHash(ctx, tx.Hash())
+		if err == nil && !isPending {
+			return nil, ErrAlreadyMined
+		}
+		if err != nil && err != ethereum.NotFound {
+			return nil, err
+		}
+	}
+
+	min := MinReplacementGasPrice(r.Latest().GasPrice(), DefaultPriceBump)
+	if gasPrice != nil {
+		if gasPrice.Cmp(min) < 0 {
+			return nil, ErrReplacementUnderpriced
+		}
+		return gasPrice, nil
+	}
+	suggested, err := client.SuggestGasPrice(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if suggested.Cmp(min) > 0 {
+		return suggested, nil
+	}
+	return min, nil
+}
+
+func (r *Replacement) send(ctx context.Context, tx *types.Transaction) error {
+	signed, err := r.transactor.Send(ctx, tx)
+	if err != nil {
+		return err
+	}
+	r.txs = append(r.txs, signed)
+	return nil
+}
+
+// SpeedUp re-sends the pending transaction tx with the same nonce and a
+// higher gas price, and returns the replacement chain. If gasPrice is nil,
+// the larger of the minimum replacement price and the node's suggestion is
+// used.
+func (t *Transactor) SpeedUp(ctx context.Context, tx *types.Transaction, gasPrice *big.Int) (*Replacement, error) {
+	r := &Replacement{transactor: t, txs: []*types.Transaction{tx}}
+	if err := r.SpeedUp(ctx, gasPrice); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Cancel replaces the pending transaction tx with a zero-value transfer to
+// the sender itself, and returns the replacement chain. If gasPrice is nil,
+// the larger of the minimum replacement price and the node's suggestion is
+// used.
+func (t *Transactor) Cancel(ctx context.Context, tx *types.Transaction, gasPrice *big.Int) (*Replacement, error) {
+	r := &Replacement{transactor: t, txs: []*types.Transaction{tx}}
+	if err := r.Cancel(ctx, gasPrice); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
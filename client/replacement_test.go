@@ -0,0 +1,122 @@
+// Copyright 2017 AMIS Technologies
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package client
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func TestMinReplacementGasPrice(t *testing.T) {
+	for _, test := range []struct {
+		price, bump, want int64
+	}{
+		{0, 10, 1},
+		{5, 10, 6},
+		{10, 10, 11},
+		{100, 10, 110},
+		{100, 0, 101},
+		{1000000000, 12, 1120000000},
+	} {
+		if have := MinReplacementGasPrice(big.NewInt(test.price), uint64(test.bump)); have.Int64() != test.want {
+			t.Errorf("price %d, bump %d: have %v, want %d", test.price, test.bump, have, test.want)
+		}
+	}
+}
+
+func TestReplacement(t *testing.T) {
+	pool := &FakeTxPool{gasPrice: 1}
+	c := newTestNodeClient(t, pool)
+	defer c.Close()
+	ctx := context.Background()
+
+	key, _ := crypto.GenerateKey()
+	transactor := NewTransactor(c, NewKeySigner(key), big.NewInt(2017))
+	to := common.HexToAddress("0x1234")
+	tx, err := transactor.Send(ctx, types.NewTransaction(7, to, big.NewInt(1), big.NewInt(21000), big.NewInt(100), []byte{1}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Replacements must pay at least the txpool price bump.
+	if _, err := transactor.SpeedUp(ctx, tx, big.NewInt(109)); !errors.Is(err, ErrReplacementUnderpriced) {
+		t.Fatalf("error mismatch: have %v, want %v", err, ErrReplacementUnderpriced)
+	}
+	r, err := transactor.SpeedUp(ctx, tx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if have := r.Latest().GasPrice(); have.Int64() != 110 {
+		t.Errorf("gas price mismatch: have %v, want 110", have)
+	}
+
+	// Later replacements bump over the latest transaction, preferring a
+	// higher suggestion of the node.
+	pool.gasPrice = 500
+	if err := r.SpeedUp(ctx, big.NewInt(120)); !errors.Is(err, ErrReplacementUnderpriced) {
+		t.Fatalf("error mismatch: have %v, want %v", err, ErrReplacementUnderpriced)
+	}
+	if err := r.SpeedUp(ctx, nil); err != nil {
+		t.Fatal(err)
+	}
+	if have := r.Latest().GasPrice(); have.Int64() != 500 {
+		t.Errorf("gas price mismatch: have %v, want 500", have)
+	}
+	if err := r.Cancel(ctx, big.NewInt(600)); err != nil {
+		t.Fatal(err)
+	}
+	cancel := r.Latest()
+	if *cancel.To() != transactor.Address() || cancel.Value().Sign() != 0 || len(cancel.Data()) != 0 {
+		t.Errorf("unexpected cancel transaction: to %x, value %v, data %x", cancel.To(), cancel.Value(), cancel.Data())
+	}
+
+	// Every version reuses the nonce and stays tracked.
+	txs := r.Transactions()
+	if len(txs) != 4 || txs[0].Hash() != tx.Hash() {
+		t.Fatalf("unexpected chain: %x", r.Hashes())
+	}
+	for i, tx := range txs {
+		if tx.Nonce() != 7 {
+			t.Errorf("transaction %d: have nonce %d, want 7", i, tx.Nonce())
+		}
+		if pool.sent[i] != tx.Hash() {
+			t.Errorf("transaction %d: not broadcast", i)
+		}
+	}
+
+	// An older version being mined ends the chain.
+	pool.mine(txs[1], types.ReceiptStatusSuccessful)
+	mined, receipt, err := r.Wait(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mined.Hash() != txs[1].Hash() || receipt.TxHash != txs[1].Hash() {
+		t.Errorf("mined mismatch: have %x, want %x", mined.Hash(), txs[1].Hash())
+	}
+	if err := r.SpeedUp(ctx, nil); err != ErrAlreadyMined {
+		t.Errorf("error mismatch: have %v, want %v", err, ErrAlreadyMined)
+	}
+	if _, err := transactor.Cancel(ctx, txs[1], nil); err != ErrAlreadyMined {
+		t.Errorf("error mismatch: have %v, want %v", err, ErrAlreadyMined)
+	}
+}
@@ -0,0 +1,51 @@
+// Copyright 2017 AMIS Technologies
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package client
+
+import "strings"
+
+// RevertError is returned if a call or transaction was reverted. Reason is
+// empty if the contract did not provide one.
+type RevertError struct {
+	Reason string
+}
+
+func (e *RevertError) Error() string {
+	if e.Reason == "" {
+		return "execution reverted"
+	}
+	return "execution reverted: " + e.Reason
+}
+
+// DecodeRevert inspects the error of eth_call or eth_estimateGas and returns
+// a *RevertError if the execution was reverted. Otherwise err is returned
+// unchanged. The output of successful calls is never taken for a revert,
+// even if it looks like a revert payload.
+func DecodeRevert(err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := err.(*RevertError); ok {
+		return err
+	}
+	msg := err.Error()
+	if strings.HasPrefix(msg, "execution reverted") {
+		reason := strings.TrimPrefix(strings.TrimPrefix(msg, "execution reverted"), ": ")
+		return &RevertError{Reason: reason}
+	}
+	return err
+}
@@ -0,0 +1,91 @@
+// Copyright 2017 AMIS Technologies
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package client
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// revertPayload returns the Error(string) payload emitted by Solidity's
+// revert and require.
+func revertPayload(reason string) []byte {
+	data := []byte{0x08, 0xc3, 0x79, 0xa0}
+	data = append(data, common.LeftPadBytes(big.NewInt(32).Bytes(), 32)...)
+	data = append(data, common.LeftPadBytes(big.NewInt(int64(len(reason))).Bytes(), 32)...)
+	return append(data, common.RightPadBytes([]byte(reason), (len(reason)+31)/32*32)...)
+}
+
+func TestDecodeRevert(t *testing.T) {
+	plain := errors.New("connection refused")
+
+	tests := []struct {
+		err    error
+		reason string
+		revert bool
+	}{
+		{},
+		{err: errors.New("execution reverted"), revert: true},
+		{err: errors.New("execution reverted: paused"), reason: "paused", revert: true},
+		{err: &RevertError{Reason: "paused"}, reason: "paused", revert: true},
+		{err: plain},
+	}
+	for i, test := range tests {
+		err := DecodeRevert(test.err)
+		revert, ok := err.(*RevertError)
+		if ok != test.revert {
+			t.Errorf("test %d: revert mismatch: have %v, want %v", i, err, test.revert)
+			continue
+		}
+		if ok && revert.Reason != test.reason {
+			t.Errorf("test %d: reason mismatch: have %q, want %q", i, revert.Reason, test.reason)
+		}
+		if !ok && err != test.err {
+			t.Errorf("test %d: error mismatch: have %v, want %v", i, err, test.err)
+		}
+	}
+}
+
+func TestCallRevertOutput(t *testing.T) {
+	// A successful call returning data which looks like a revert payload is
+	// not a revert.
+	payload := revertPayload("caller is not the owner")
+	state := &FakeState{results: map[string]hexutil.Bytes{"0x01": payload}}
+	c := newTestNodeClient(t, state)
+	defer c.Close()
+
+	to := common.HexToAddress("0x1234")
+	output, err := c.CallContract(context.Background(), ethereum.CallMsg{To: &to, Data: []byte{0x01}}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !bytes.Equal(output, payload) {
+		t.Errorf("output mismatch: have %x, want %x", output, payload)
+	}
+
+	_, err = c.CallContract(context.Background(), ethereum.CallMsg{To: &to, Data: []byte("revert")}, nil)
+	if revert, ok := err.(*RevertError); !ok || revert.Reason != "reverted" {
+		t.Errorf("error mismatch: have %v, want revert", err)
+	}
+}
@@ -0,0 +1,74 @@
+// Copyright 2017 AMIS Technologies
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package client
+
+import (
+	"context"
+	"errors"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+var (
+	// ErrSenderMismatch is returned if a signed transaction does not recover to
+	// the account it was signed for.
+	ErrSenderMismatch = errors.New("signed transaction sender mismatch")
+	// ErrSignedTxMismatch is returned if a signer returns a transaction whose
+	// content differs from the one it was asked to sign.
+	ErrSignedTxMismatch = errors.New("signed transaction does not match the request")
+)
+
+// Signer signs transactions on behalf of a single account.
+type Signer interface {
+	// Address returns the account the signer signs for.
+	Address() common.Address
+	// SignTx returns a copy of the given transaction signed for the given chain.
+	SignTx(ctx context.Context, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error)
+}
+
+// verifySignedTx checks that signed carries the same content as unsigned and
+// recovers to the expected sender.
+func verifySignedTx(unsigned, signed *types.Transaction, chainID *big.Int, from common.Address) error {
+	signer := types.NewEIP155Signer(chainID)
+	if signer.Hash(unsigned) != signer.Hash(signed) {
+		return ErrSignedTxMismatch
+	}
+	sender, err := types.Sender(signer, signed)
+	if err != nil {
+		return err
+	}
+	if sender != from {
+		return ErrSenderMismatch
+	}
+	return nil
+}
+
+// txSender recovers the sender of a signed transaction of the chain with the
+// given ID, using the EIP155 signer for replay protected transactions. The
+// chain ID may be nil if it is unknown; see isPrivateTx for how it affects
+// Quorum private transactions.
+func txSender(tx *types.Transaction, chainID *big.Int) (common.Address, error) {
+	if isPrivateTx(tx, chainID) {
+		return types.Sender(PrivateTxSigner{}, tx)
+	}
+	if tx.Protected() {
+		return types.Sender(types.NewEIP155Signer(tx.ChainId()), tx)
+	}
+	return types.Sender(types.HomesteadSigner{}, tx)
+}
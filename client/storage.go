@@ -0,0 +1,310 @@
+// Copyright 2017 AMIS Technologies
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	ethrpc "github.com/ethereum/go-ethereum/rpc"
+)
+
+var (
+	// ErrIndexOutOfRange is returned when reading beyond the length of a
+	// dynamic storage array.
+	ErrIndexOutOfRange = errors.New("storage array index out of range")
+	// ErrInvalidElementSize is returned for array elements without a
+	// positive size, or larger than the single word they are read from.
+	ErrInvalidElementSize = errors.New("invalid storage array element size")
+)
+
+// The functions below compute storage slots following Solidity's layout
+// rules. Nested mappings and arrays are addressed by chaining them, e.g. the
+// slot of m[a][b] is MappingSlot(MappingSlot(slot, a), b).
+
+// SlotAdd returns the slot n slots after slot, as used by the members of
+// structs and static arrays.
+func SlotAdd(slot common.Hash, n uint64) common.Hash {
+	return common.BigToHash(new(big.Int).Add(slot.Big(), new(big.Int).SetUint64(n)))
+}
+
+// MappingSlot returns the slot of the value stored under a value type key in
+// the mapping at slot. Keys are 32-byte words as ABI encoded, see
+// AddressKey and IntKey.
+func MappingSlot(slot common.Hash, key common.Hash) common.Hash {
+	return crypto.Keccak256Hash(key.Bytes(), slot.Bytes())
+}
+
+// MappingSlotBytes returns the slot of the value stored under a string or
+// bytes key in the mapping at slot.
+func MappingSlotBytes(slot common.Hash, key []byte) common.Hash {
+	return crypto.Keccak256Hash(key, slot.Bytes())
+}
+
+// AddressKey encodes an address as a mapping key.
+func AddressKey(addr common.Address) common.Hash {
+	return addr.Hash()
+}
+
+// IntKey encodes an integer as a mapping key, using the two's complement
+// representation for negative values.
+func IntKey(v *big.Int) common.Hash {
+	if v.Sign() < 0 {
+		v = new(big.Int).Add(v, new(big.Int).Lsh(common.Big1, 256))
+	}
+	return common.BigToHash(v)
+}
+
+// ArrayDataSlot returns the first slot of the elements of the dynamic array,
+// string or bytes whose length is stored at slot.
+func ArrayDataSlot(slot common.Hash) common.Hash {
+	return crypto.Keccak256Hash(slot.Bytes())
+}
+
+// ArrayElementSlot returns the slot and the offset in bytes from the
+// low-order end of the slot of the element at index of the dynamic array at
+// slot. Elements of elemSize bytes are packed if elemSize is below 32;
+// larger elements, such as structs, span elemSize/32 slots.
+func ArrayElementSlot(slot common.Hash, index uint64, elemSize int) (common.Hash, int, error) {
+	if elemSize <= 0 {
+		return common.Hash{}, 0, ErrInvalidElementSize
+	}
+	start := ArrayDataSlot(slot)
+	if elemSize >= 32 {
+		return SlotAdd(start, index*uint64((elemSize+31)/32)), 0, nil
+	}
+	perSlot := uint64(32 / elemSize)
+	return SlotAdd(start, index/perSlot), int(index%perSlot) * elemSize, nil
+}
+
+// ExtractPacked returns size bytes of a storage word at offset bytes from
+// its low-order end, where Solidity places packed values.
+func ExtractPacked(word common.Hash, offset, size int) ([]byte, error) {
+	if err := checkPacked(offset, size); err != nil {
+		return nil, err
+	}
+	return common.CopyBytes(word[32-offset-size : 32-offset]), nil
+}
+
+// checkPacked verifies that a packed value lies within a storage word.
+func checkPacked(offset, size int) error {
+	if offset < 0 || size <= 0 || offset+size > 32 {
+		return fmt.Errorf("invalid packed value at offset %d with size %d", offset, size)
+	}
+	return nil
+}
+
+// StorageReader reads and decodes the storage of a contract at any block.
+type StorageReader struct {
+	client  Client
+	address common.Address
+}
+
+// NewStorageReader creates a reader for the storage of the contract at
+// address.
+func NewStorageReader(client Client, address common.Address) *StorageReader {
+	return &StorageReader{
+		client:  client,
+		address: address,
+	}
+}
+
+// Word returns the storage word at slot. A nil blockNumber reads the latest
+// state.
+func (r *StorageReader) Word(ctx context.Context, slot common.Hash, blockNumber *big.Int) (common.Hash, error) {
+	value, err := r.client.StorageAt(ctx, r.address, slot, blockNumber)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return common.BytesToHash(value), nil
+}
+
+// Packed returns size bytes stored at offset bytes from the low-order end of
+// slot.
+func (r *StorageReader) Packed(ctx context.Context, slot common.Hash, offset, size int, blockNumber *big.Int) ([]byte, error) {
+	if err := checkPacked(offset, size); err != nil {
+		return nil, err
+	}
+	word, err := r.Word(ctx, slot, blockNumber)
+	if err != nil {
+		return nil, err
+	}
+	return ExtractPacked(word, offset, size)
+}
+
+// Uint returns an unsigned integer of size bytes stored at offset in slot.
+func (r *StorageReader) Uint(ctx context.Context, slot common.Hash, offset, size int, blockNumber *big.Int) (*big.Int, error) {
+	value, err := r.Packed(ctx, slot, offset, size, blockNumber)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(value), nil
+}
+
+// Int returns a signed integer of size bytes stored at offset in slot.
+func (r *StorageReader) Int(ctx context.Context, slot common.Hash, offset, size int, blockNumber *big.Int) (*big.Int, error) {
+	value, err := r.Uint(ctx, slot, offset, size, blockNumber)
+	if err != nil {
+		return nil, err
+	}
+	if value.Bit(size*8-1) == 1 {
+		value.Sub(value, new(big.Int).Lsh(common.Big1, uint(size*8)))
+	}
+	return value, nil
+}
+
+// Address returns an address stored at offset in slot.
+func (r *StorageReader) Address(ctx context.Context, slot common.Hash, offset int, blockNumber *big.Int) (common.Address, error) {
+	value, err := r.Packed(ctx, slot, offset, common.AddressLength, blockNumber)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return common.BytesToAddress(value), nil
+}
+
+// Bool returns a boolean stored at offset in slot.
+func (r *StorageReader) Bool(ctx context.Context, slot common.Hash, offset int, blockNumber *big.Int) (bool, error) {
+	value, err := r.Packed(ctx, slot, offset, 1, blockNumber)
+	if err != nil {
+		return false, err
+	}
+	return value[0] != 0, nil
+}
+
+// Mapping returns the storage word of the value stored under key in the
+// mapping at slot.
+func (r *StorageReader) Mapping(ctx context.Context, slot common.Hash, key common.Hash, blockNumber *big.Int) (common.Hash, error) {
+	return r.Word(ctx, MappingSlot(slot, key), blockNumber)
+}
+
+// ArrayLength returns the length of the dynamic array at slot.
+func (r *StorageReader) ArrayLength(ctx context.Context, slot common.Hash, blockNumber *big.Int) (*big.Int, error) {
+	word, err := r.Word(ctx, slot, blockNumber)
+	if err != nil {
+		return nil, err
+	}
+	return word.Big(), nil
+}
+
+// ArrayElement returns the element at index of the dynamic array at slot,
+// whose elements take elemSize bytes (at most 32). The index is checked
+// against the array length.
+func (r *StorageReader) ArrayElement(ctx context.Context, slot common.Hash, index uint64, elemSize int, blockNumber *big.Int) ([]byte, error) {
+	if elemSize > 32 {
+		return nil, ErrInvalidElementSize
+	}
+	elemSlot, offset, err := ArrayElementSlot(slot, index, elemSize)
+	if err != nil {
+		return nil, err
+	}
+	length, err := r.ArrayLength(ctx, slot, blockNumber)
+	if err != nil {
+		return nil, err
+	}
+	if new(big.Int).SetUint64(index).Cmp(length) >= 0 {
+		return nil, ErrIndexOutOfRange
+	}
+	if elemSize == 32 {
+		word, err := r.Word(ctx, elemSlot, blockNumber)
+		if err != nil {
+			return nil, err
+		}
+		return word.Bytes(), nil
+	}
+	return r.Packed(ctx, elemSlot, offset, elemSize, blockNumber)
+}
+
+// Bytes returns the bytes value stored at slot. Values shorter than 32 bytes
+// are stored in the slot itself, longer ones span the slots starting at
+// ArrayDataSlot(slot), which are read in JSON-RPC batches.
+func (r *StorageReader) Bytes(ctx context.Context, slot common.Hash, blockNumber *big.Int) ([]byte, error) {
+	word, err := r.Word(ctx, slot, blockNumber)
+	if err != nil {
+		return nil, err
+	}
+	if word[31]&1 == 0 {
+		// Short layout: the data is left aligned, the last byte is length*2.
+		size := int(word[31]) / 2
+		if size > 31 {
+			return nil, errors.New("invalid short bytes encoding")
+		}
+		return common.CopyBytes(word[:size]), nil
+	}
+
+	// Long layout: the slot holds length*2+1.
+	size := new(big.Int).Rsh(word.Big(), 1)
+	if size.BitLen() > 24 {
+		return nil, fmt.Errorf("bytes value too large: %v", size)
+	}
+	n := int(size.Uint64())
+	words, err := r.words(ctx, ArrayDataSlot(slot), (n+31)/32, blockNumber)
+	if err != nil {
+		return nil, err
+	}
+	data := make([]byte, 0, len(words)*32)
+	for _, w := range words {
+		data = append(data, common.BytesToHash(w).Bytes()...)
+	}
+	return data[:n], nil
+}
+
+// words reads count consecutive storage words starting at slot, sending at
+// most DefaultMulticallChunkSize reads in a single batch.
+func (r *StorageReader) words(ctx context.Context, slot common.Hash, count int, blockNumber *big.Int) ([]hexutil.Bytes, error) {
+	block := "latest"
+	if blockNumber != nil {
+		block = hexutil.EncodeBig(blockNumber)
+	}
+	words := make([]hexutil.Bytes, count)
+	for start := 0; start < count; start += DefaultMulticallChunkSize {
+		end := start + DefaultMulticallChunkSize
+		if end > count {
+			end = count
+		}
+		batch := make([]ethrpc.BatchElem, end-start)
+		for i := range batch {
+			batch[i] = ethrpc.BatchElem{
+				Method: "eth_getStorageAt",
+				Args:   []interface{}{r.address, SlotAdd(slot, uint64(start+i)), block},
+				Result: &words[start+i],
+			}
+		}
+		if err := r.client.BatchCallContext(ctx, batch); err != nil {
+			return nil, err
+		}
+		for _, elem := range batch {
+			if elem.Error != nil {
+				return nil, ClassifyError(elem.Error)
+			}
+		}
+	}
+	return words, nil
+}
+
+// String returns the string value stored at slot.
+func (r *StorageReader) String(ctx context.Context, slot common.Hash, blockNumber *big.Int) (string, error) {
+	data, err := r.Bytes(ctx, slot, blockNumber)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
@@ -0,0 +1,159 @@
+// Copyright 2017 AMIS Technologies
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package client
+
+import (
+	"bytes"
+	"context"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func TestStorageSlots(t *testing.T) {
+	slot := common.BigToHash(big.NewInt(3))
+	addr := common.HexToAddress("0x00000000000000000000000000000000000000aa")
+
+	if have, want := MappingSlot(slot, AddressKey(addr)), crypto.Keccak256Hash(common.LeftPadBytes(addr.Bytes(), 32), common.LeftPadBytes([]byte{3}, 32)); have != want {
+		t.Errorf("mapping slot mismatch: have %x, want %x", have, want)
+	}
+	if have := IntKey(big.NewInt(-1)); have != common.BytesToHash(bytes.Repeat([]byte{0xff}, 32)) {
+		t.Errorf("unexpected negative key: %x", have)
+	}
+
+	start := ArrayDataSlot(slot)
+	tests := []struct {
+		index    uint64
+		elemSize int
+		slot     common.Hash
+		offset   int
+	}{
+		{0, 32, start, 0},
+		{2, 32, SlotAdd(start, 2), 0},
+		{1, 64, SlotAdd(start, 2), 0},
+		{0, 8, start, 0},
+		{3, 8, start, 24},
+		{5, 8, SlotAdd(start, 1), 8},
+		{10, 20, SlotAdd(start, 10), 0},
+	}
+	for _, test := range tests {
+		slot, offset, err := ArrayElementSlot(common.BigToHash(big.NewInt(3)), test.index, test.elemSize)
+		if err != nil {
+			t.Errorf("element %d of size %d: unexpected error: %v", test.index, test.elemSize, err)
+		} else if slot != test.slot || offset != test.offset {
+			t.Errorf("element %d of size %d: have %x+%d, want %x+%d", test.index, test.elemSize, slot, offset, test.slot, test.offset)
+		}
+	}
+	if _, _, err := ArrayElementSlot(start, 1, 0); err != ErrInvalidElementSize {
+		t.Errorf("error mismatch: have %v, want %v", err, ErrInvalidElementSize)
+	}
+}
+
+func TestExtractPacked(t *testing.T) {
+	var word common.Hash
+	for i := range word {
+		word[i] = byte(i)
+	}
+	if v, err := ExtractPacked(word, 8, 4); err != nil || !bytes.Equal(v, []byte{20, 21, 22, 23}) {
+		t.Errorf("packed value mismatch: have %x, %v, want 14151617", v, err)
+	}
+	for _, r := range [][2]int{{-1, 4}, {0, 0}, {30, 4}, {0, 33}} {
+		if _, err := ExtractPacked(word, r[0], r[1]); err == nil {
+			t.Errorf("offset %d, size %d: expected an error", r[0], r[1])
+		}
+	}
+}
+
+func TestStorageReader(t *testing.T) {
+	// Long enough to be read in more than one batch.
+	long := strings.Repeat("storage layout ", 3*DefaultMulticallChunkSize)
+	owner := common.HexToAddress("0x00000000000000000000000000000000000000aa")
+
+	state := &FakeState{storage: make(map[common.Hash]common.Hash)}
+	slot := func(i int64) common.Hash { return common.BigToHash(big.NewInt(i)) }
+
+	// slot 0: uint64(7) | address owner | bool true
+	var packed common.Hash
+	packed[31] = 7
+	copy(packed[4:24], owner.Bytes())
+	packed[3] = 1
+	state.storage[slot(0)] = packed
+
+	// slot 1: short string
+	var short common.Hash
+	copy(short[:], "hello")
+	short[31] = 10
+	state.storage[slot(1)] = short
+
+	// slot 2: long string
+	state.storage[slot(2)] = common.BigToHash(big.NewInt(int64(len(long)*2 + 1)))
+	for i := 0; i*32 < len(long); i++ {
+		end := (i + 1) * 32
+		if end > len(long) {
+			end = len(long)
+		}
+		var chunk common.Hash
+		copy(chunk[:], long[i*32:end])
+		state.storage[SlotAdd(ArrayDataSlot(slot(2)), uint64(i))] = chunk
+	}
+
+	// slot 3: uint16[] {1, 2, 3}
+	state.storage[slot(3)] = common.BigToHash(big.NewInt(3))
+	var elems common.Hash
+	elems[31], elems[29], elems[27] = 1, 2, 3
+	state.storage[ArrayDataSlot(slot(3))] = elems
+
+	// slot 4: mapping(address => uint256)
+	state.storage[MappingSlot(slot(4), AddressKey(owner))] = common.BigToHash(big.NewInt(42))
+
+	ctx := context.Background()
+	r := NewStorageReader(newTestNodeClient(t, state), common.Address{})
+
+	if v, err := r.Uint(ctx, slot(0), 0, 8, nil); err != nil || v.Int64() != 7 {
+		t.Errorf("unexpected uint: %v, %v", v, err)
+	}
+	if v, err := r.Address(ctx, slot(0), 8, nil); err != nil || v != owner {
+		t.Errorf("unexpected address: %x, %v", v, err)
+	}
+	if v, err := r.Bool(ctx, slot(0), 28, nil); err != nil || !v {
+		t.Errorf("unexpected bool: %v, %v", v, err)
+	}
+	if v, err := r.String(ctx, slot(1), nil); err != nil || v != "hello" {
+		t.Errorf("unexpected short string: %q, %v", v, err)
+	}
+	if v, err := r.String(ctx, slot(2), nil); err != nil || v != long {
+		t.Errorf("unexpected long string: %q, %v", v, err)
+	}
+	if v, err := r.ArrayElement(ctx, slot(3), 2, 2, nil); err != nil || !bytes.Equal(v, []byte{0, 3}) {
+		t.Errorf("unexpected array element: %x, %v", v, err)
+	}
+	if _, err := r.ArrayElement(ctx, slot(3), 3, 2, nil); err != ErrIndexOutOfRange {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if _, err := r.ArrayElement(ctx, slot(3), 0, 0, nil); err != ErrInvalidElementSize {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if _, err := r.ArrayElement(ctx, slot(3), 0, 64, nil); err != ErrInvalidElementSize {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if v, err := r.Mapping(ctx, slot(4), AddressKey(owner), nil); err != nil || v.Big().Int64() != 42 {
+		t.Errorf("unexpected mapping value: %x, %v", v, err)
+	}
+}
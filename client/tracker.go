@@ -0,0 +1,354 @@
+// Copyright 2017 AMIS Technologies
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package client
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+)
+
+// TxStatus is the status of a tracked transaction.
+type TxStatus int
+
+const (
+	// TxPending means the transaction is waiting to be mined.
+	TxPending TxStatus = iota
+	// TxMined means the transaction was mined and executed successfully.
+	TxMined
+	// TxFailed means the transaction was mined but its execution failed.
+	TxFailed
+	// TxDropped means the transaction went missing and could not be rebroadcast.
+	TxDropped
+	// TxReplaced means another transaction with the same nonce was mined.
+	TxReplaced
+)
+
+func (s TxStatus) String() string {
+	switch s {
+	case TxPending:
+		return "pending"
+	case TxMined:
+		return "mined"
+	case TxFailed:
+		return "failed"
+	case TxDropped:
+		return "dropped"
+	case TxReplaced:
+		return "replaced"
+	}
+	return "unknown"
+}
+
+// TxStatusEvent is posted when the status of a tracked transaction changes.
+// Receipt is only set for mined and failed transactions.
+type TxStatusEvent struct {
+	Tx      *types.Transaction
+	Status  TxStatus
+	Receipt *types.Receipt
+}
+
+// TrackerConfig contains the settings of a transaction tracker.
+type TrackerConfig struct {
+	// Interval is the time between two status checks.
+	Interval time.Duration
+	// MaxRebroadcasts is the number of times a missing transaction is
+	// rebroadcast before it is reported as dropped. Zero takes the default.
+	MaxRebroadcasts int
+	// Store persists the tracked transactions, if not nil.
+	Store TrackerStore
+	// ChainID is the chain of the tracked transactions. It is only needed to
+	// recognize Quorum private transactions, on chains other than 1.
+	ChainID *big.Int
+}
+
+// DefaultTrackerConfig contains the default tracker settings.
+var DefaultTrackerConfig = TrackerConfig{
+	Interval:        15 * time.Second,
+	MaxRebroadcasts: 10,
+}
+
+// Tracker follows transactions sent through it in the background. It
+// rebroadcasts raw transactions that went missing from the node's pool and
+// reports status changes to its subscribers. Transactions which reach a
+// final status are no longer tracked.
+type Tracker struct {
+	client Client
+	config TrackerConfig
+
+	mu  sync.Mutex
+	txs map[common.Hash]*TrackedTx
+
+	// queue holds the events not yet sent to the subscribers, so slow
+	// subscribers do not hold up the status checks.
+	queue  []TxStatusEvent
+	notify chan struct{}
+
+	feed  event.Feed
+	scope event.SubscriptionScope
+	quit  chan struct{}
+	stop  sync.Once
+	wg    sync.WaitGroup
+}
+
+// NewTracker creates a tracker on top of client and restores the
+// transactions saved in the configured store.
+func NewTracker(client Client, config TrackerConfig) (*Tracker, error) {
+	if config.Interval <= 0 {
+		config.Interval = DefaultTrackerConfig.Interval
+	}
+	if config.MaxRebroadcasts <= 0 {
+		config.MaxRebroadcasts = DefaultTrackerConfig.MaxRebroadcasts
+	}
+	t := &Tracker{
+		client: client,
+		config: config,
+		txs:    make(map[common.Hash]*TrackedTx),
+		notify: make(chan struct{}, 1),
+		quit:   make(chan struct{}),
+	}
+	if config.Store != nil {
+		txs, err := config.Store.Load()
+		if err != nil {
+			return nil, err
+		}
+		for _, tx := range txs {
+			t.txs[tx.Tx.Hash()] = tx
+		}
+	}
+	return t, nil
+}
+
+// Start starts the background status checks and the delivery of status
+// events to the subscribers.
+func (t *Tracker) Start() {
+	t.wg.Add(2)
+	go t.loop()
+	go t.sendLoop()
+}
+
+// Stop stops the background status checks and closes all subscriptions. It
+// may be called without a prior Start, and more than once.
+func (t *Tracker) Stop() {
+	t.stop.Do(func() {
+		close(t.quit)
+		t.scope.Close()
+	})
+	t.wg.Wait()
+}
+
+// SubscribeStatus subscribes to status changes of tracked transactions.
+func (t *Tracker) SubscribeStatus(ch chan<- TxStatusEvent) event.Subscription {
+	return t.scope.Track(t.feed.Subscribe(ch))
+}
+
+// SendRawTransaction broadcasts a signed transaction and starts tracking it.
+func (t *Tracker) SendRawTransaction(ctx context.Context, tx *types.Transaction) error {
+	if err := t.client.SendRawTransaction(ctx, tx); err != nil {
+		return err
+	}
+	return t.Track(tx)
+}
+
+// Track starts tracking a signed transaction which has already been sent, and
+// reports it as pending.
+func (t *Tracker) Track(tx *types.Transaction) error {
+	t.mu.Lock()
+	if _, ok := t.txs[tx.Hash()]; ok {
+		t.mu.Unlock()
+		return nil
+	}
+	t.txs[tx.Hash()] = &TrackedTx{Tx: tx}
+	err := t.save()
+	t.mu.Unlock()
+
+	t.post(TxStatusEvent{Tx: tx, Status: TxPending})
+	return err
+}
+
+// Transactions returns the transactions which are still being tracked.
+func (t *Tracker) Transactions() []*types.Transaction {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	txs := make([]*types.Transaction, 0, len(t.txs))
+	for _, tx := range t.txs {
+		txs = append(txs, tx.Tx)
+	}
+	return txs
+}
+
+func (t *Tracker) loop() {
+	defer t.wg.Done()
+
+	ticker := time.NewTicker(t.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-t.quit:
+			return
+		case <-ticker.C:
+			t.checkAll()
+		}
+	}
+}
+
+func (t *Tracker) checkAll() {
+	t.mu.Lock()
+	txs := make([]*TrackedTx, 0, len(t.txs))
+	for _, tx := range t.txs {
+		txs = append(txs, tx)
+	}
+	t.mu.Unlock()
+
+	var events []TxStatusEvent
+	for _, tx := range txs {
+		ctx, cancel := context.WithTimeout(context.Background(), t.config.Interval)
+		ev, err := t.check(ctx, tx)
+		cancel()
+		if err != nil {
+			log.Debug("Failed to check transaction", "hash", tx.Tx.Hash().Hex(), "err", err)
+			continue
+		}
+		if ev != nil {
+			events = append(events, *ev)
+		}
+	}
+
+	t.mu.Lock()
+	for _, ev := range events {
+		if ev.Status != TxPending {
+			delete(t.txs, ev.Tx.Hash())
+		}
+	}
+	if err := t.save(); err != nil {
+		log.Warn("Failed to save tracked transactions", "err", err)
+	}
+	t.mu.Unlock()
+
+	t.post(events...)
+}
+
+// post queues events for delivery to the subscribers without blocking.
+func (t *Tracker) post(events ...TxStatusEvent) {
+	if len(events) == 0 {
+		return
+	}
+	t.mu.Lock()
+	t.queue = append(t.queue, events...)
+	t.mu.Unlock()
+
+	select {
+	case t.notify <- struct{}{}:
+	default:
+	}
+}
+
+// sendLoop delivers the queued events to the subscribers in order.
+func (t *Tracker) sendLoop() {
+	defer t.wg.Done()
+
+	for {
+		select {
+		case <-t.quit:
+			return
+		case <-t.notify:
+		}
+		for {
+			t.mu.Lock()
+			if len(t.queue) == 0 {
+				t.mu.Unlock()
+				break
+			}
+			ev := t.queue[0]
+			t.queue = t.queue[1:]
+			t.mu.Unlock()
+
+			t.feed.Send(ev)
+		}
+	}
+}
+
+// check updates the status of tx and returns an event if it has reached a
+// final status, or if it has been rebroadcast and is pending again.
+func (t *Tracker) check(ctx context.Context, tx *TrackedTx) (*TxStatusEvent, error) {
+	hash := tx.Tx.Hash()
+	receipt, err := t.client.TransactionReceipt(ctx, hash)
+	if err == nil {
+		status := TxMined
+		if receiptFailed(receipt) {
+			status = TxFailed
+		}
+		return &TxStatusEvent{Tx: tx.Tx, Status: status, Receipt: receipt}, nil
+	}
+	if err != ethereum.NotFound {
+		return nil, err
+	}
+
+	_, _, err = t.client.TransactionByHash(ctx, hash)
+	if err == nil {
+		return nil, nil
+	}
+	if err != ethereum.NotFound {
+		return nil, err
+	}
+
+	// The transaction went missing, check whether its nonce has been used.
+	sender, err := txSender(tx.Tx, t.config.ChainID)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := t.client.NonceAt(ctx, sender, nil)
+	if err != nil {
+		return nil, err
+	}
+	if nonce > tx.Tx.Nonce() {
+		return &TxStatusEvent{Tx: tx.Tx, Status: TxReplaced}, nil
+	}
+	if tx.Rebroadcasts >= t.config.MaxRebroadcasts {
+		return &TxStatusEvent{Tx: tx.Tx, Status: TxDropped}, nil
+	}
+
+	t.mu.Lock()
+	tx.Rebroadcasts++
+	t.mu.Unlock()
+	log.Info("Rebroadcasting missing transaction", "hash", hash.Hex(), "attempt", tx.Rebroadcasts)
+	if err := t.client.SendRawTransaction(ctx, tx.Tx); err != nil && !errors.Is(err, ErrKnownTransaction) {
+		return nil, err
+	}
+	return &TxStatusEvent{Tx: tx.Tx, Status: TxPending}, nil
+}
+
+// save persists the tracked transactions. The caller must hold t.mu.
+func (t *Tracker) save() error {
+	if t.config.Store == nil {
+		return nil
+	}
+	txs := make([]*TrackedTx, 0, len(t.txs))
+	for _, tx := range t.txs {
+		txs = append(txs, tx)
+	}
+	return t.config.Store.Save(txs)
+}
@@ -0,0 +1,122 @@
+// Copyright 2017 AMIS Technologies
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package client
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// TrackedTx is a transaction followed by a tracker.
+type TrackedTx struct {
+	Tx           *types.Transaction
+	Rebroadcasts int
+}
+
+type trackedTxJSON struct {
+	Raw          hexutil.Bytes `json:"raw"`
+	Rebroadcasts int           `json:"rebroadcasts"`
+}
+
+// MarshalJSON encodes the tracked transaction with its raw RLP encoding, so
+// that it can be rebroadcast as is after a restart.
+func (tx *TrackedTx) MarshalJSON() ([]byte, error) {
+	raw, err := rlp.EncodeToBytes(tx.Tx)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(&trackedTxJSON{Raw: raw, Rebroadcasts: tx.Rebroadcasts})
+}
+
+// UnmarshalJSON decodes a tracked transaction encoded by MarshalJSON.
+func (tx *TrackedTx) UnmarshalJSON(input []byte) error {
+	var dec trackedTxJSON
+	if err := json.Unmarshal(input, &dec); err != nil {
+		return err
+	}
+	tx.Tx = new(types.Transaction)
+	if err := rlp.DecodeBytes(dec.Raw, tx.Tx); err != nil {
+		return err
+	}
+	tx.Rebroadcasts = dec.Rebroadcasts
+	return nil
+}
+
+// TrackerStore persists the state of a tracker across restarts.
+type TrackerStore interface {
+	Load() ([]*TrackedTx, error)
+	Save(txs []*TrackedTx) error
+}
+
+// fileTrackerStore keeps the tracked transactions in a JSON file.
+type fileTrackerStore struct {
+	path string
+}
+
+// NewFileTrackerStore creates a tracker store backed by the JSON file at
+// path. A missing file is treated as an empty store.
+func NewFileTrackerStore(path string) TrackerStore {
+	return &fileTrackerStore{path: path}
+}
+
+func (s *fileTrackerStore) Load() ([]*TrackedTx, error) {
+	data, err := ioutil.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var txs []*TrackedTx
+	if err := json.Unmarshal(data, &txs); err != nil {
+		return nil, err
+	}
+	return txs, nil
+}
+
+func (s *fileTrackerStore) Save(txs []*TrackedTx) error {
+	data, err := json.MarshalIndent(txs, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(s.path, data)
+}
+
+// writeFileAtomic writes data to a temporary file first and renames it to
+// path, so that a crash never leaves a truncated file behind.
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path))
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
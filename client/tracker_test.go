@@ -0,0 +1,194 @@
+// Copyright 2017 AMIS Technologies
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package client
+
+import (
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func TestTrackerRebroadcast(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tracker")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	pool := &FakeTxPool{}
+	c := newTestNodeClient(t, pool)
+	defer c.Close()
+
+	key, _ := crypto.GenerateKey()
+	tx := types.NewTransaction(0, common.HexToAddress("0x1234"), big.NewInt(1), big.NewInt(21000), big.NewInt(1), nil)
+	tx, _ = types.SignTx(tx, types.NewEIP155Signer(big.NewInt(1)), key)
+
+	config := TrackerConfig{MaxRebroadcasts: 1, Store: NewFileTrackerStore(filepath.Join(dir, "txs.json"))}
+	tracker, err := NewTracker(c, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ch := make(chan TxStatusEvent, 1)
+	tracker.SubscribeStatus(ch)
+	tracker.Start()
+	if err := tracker.Track(tx); err != nil {
+		t.Fatal(err)
+	}
+	checkStatus(t, ch, tx, TxPending)
+
+	// The transaction is unknown to the node, so it is rebroadcast.
+	tracker.checkAll()
+	if len(pool.sent) != 1 || pool.sent[0] != tx.Hash() {
+		t.Fatalf("rebroadcast mismatch: have %v, want %v", pool.sent, tx.Hash())
+	}
+	checkStatus(t, ch, tx, TxPending)
+	tracker.Stop()
+
+	// A restarted tracker resumes from the store and reports the transaction
+	// as dropped once it goes missing again.
+	delete(pool.pool, tx.Hash())
+	tracker, err = NewTracker(c, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tracker.SubscribeStatus(ch)
+	tracker.Start()
+	defer tracker.Stop()
+
+	tracker.checkAll()
+	checkStatus(t, ch, tx, TxDropped)
+	if txs := tracker.Transactions(); len(txs) != 0 {
+		t.Errorf("unexpected tracked transactions: %v", txs)
+	}
+
+	// A missing transaction whose nonce has been used by its sender was
+	// replaced. The chain 1 signature must not be taken for a Quorum private
+	// one, which would recover another sender.
+	replaced := types.NewTransaction(1, common.HexToAddress("0x1234"), big.NewInt(1), big.NewInt(21000), big.NewInt(1), nil)
+	replaced, _ = types.SignTx(replaced, types.NewEIP155Signer(big.NewInt(1)), key)
+	pool.nonces = map[common.Address]uint64{crypto.PubkeyToAddress(key.PublicKey): 2}
+	if err := tracker.Track(replaced); err != nil {
+		t.Fatal(err)
+	}
+	checkStatus(t, ch, replaced, TxPending)
+	tracker.checkAll()
+	checkStatus(t, ch, replaced, TxReplaced)
+}
+
+func TestTrackerMined(t *testing.T) {
+	pool := &FakeTxPool{}
+	c := newTestNodeClient(t, pool)
+	defer c.Close()
+
+	// A zero MaxRebroadcasts takes the default rather than dropping missing
+	// transactions at the first check.
+	tracker, err := NewTracker(c, TrackerConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tracker.config.MaxRebroadcasts != DefaultTrackerConfig.MaxRebroadcasts {
+		t.Errorf("max rebroadcasts mismatch: have %v, want %v", tracker.config.MaxRebroadcasts, DefaultTrackerConfig.MaxRebroadcasts)
+	}
+	ch := make(chan TxStatusEvent, 1)
+	tracker.SubscribeStatus(ch)
+	tracker.Start()
+	defer tracker.Stop()
+
+	key, _ := crypto.GenerateKey()
+	testCases := []struct {
+		status uint
+		want   TxStatus
+	}{
+		{types.ReceiptStatusSuccessful, TxMined},
+		{types.ReceiptStatusFailed, TxFailed},
+	}
+	for i, test := range testCases {
+		tx := types.NewTransaction(uint64(i), common.HexToAddress("0x1234"), big.NewInt(1), big.NewInt(21000), big.NewInt(1), nil)
+		tx, _ = types.SignTx(tx, types.NewEIP155Signer(big.NewInt(1)), key)
+		if err := tracker.Track(tx); err != nil {
+			t.Fatal(err)
+		}
+		checkStatus(t, ch, tx, TxPending)
+
+		pool.mine(tx, test.status)
+		tracker.checkAll()
+		checkStatus(t, ch, tx, test.want)
+	}
+	if txs := tracker.Transactions(); len(txs) != 0 {
+		t.Errorf("unexpected tracked transactions: %v", txs)
+	}
+
+	// Stopping twice must not panic.
+	tracker.Stop()
+}
+
+func TestTrackerSlowSubscriber(t *testing.T) {
+	c := newTestNodeClient(t, &FakeTxPool{})
+	defer c.Close()
+
+	// Stopping a tracker which was never started must not fail.
+	tracker, err := NewTracker(c, TrackerConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tracker.Stop()
+
+	tracker, err = NewTracker(c, TrackerConfig{MaxRebroadcasts: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tracker.SubscribeStatus(make(chan TxStatusEvent))
+	tracker.Start()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		key, _ := crypto.GenerateKey()
+		for i := 0; i < 3; i++ {
+			tx := types.NewTransaction(uint64(i), common.HexToAddress("0x1234"), big.NewInt(1), big.NewInt(21000), big.NewInt(1), nil)
+			tx, _ = types.SignTx(tx, types.NewEIP155Signer(big.NewInt(2017)), key)
+			if err := tracker.Track(tx); err != nil {
+				t.Error(err)
+			}
+		}
+		tracker.checkAll()
+		tracker.Stop()
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("tracker blocked on a subscriber which does not read")
+	}
+}
+
+func checkStatus(t *testing.T, ch <-chan TxStatusEvent, tx *types.Transaction, status TxStatus) {
+	select {
+	case ev := <-ch:
+		if ev.Status != status || ev.Tx.Hash() != tx.Hash() {
+			t.Errorf("event mismatch: have %x %v, want %x %v", ev.Tx.Hash(), ev.Status, tx.Hash(), status)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("expected a %v event", status)
+	}
+}
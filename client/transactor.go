@@ -0,0 +1,149 @@
+// Copyright 2017 AMIS Technologies
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package client
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// TxOption customizes how a transactor builds a single transaction.
+type TxOption func(*txOptions)
+
+type txOptions struct {
+	gasPriceOracle GasPriceOracle
+	gasEstimator   GasEstimator
+	nonce          *uint64
+}
+
+// WithGasPriceOracle makes the transactor take the gas price of a transaction
+// from oracle instead of the node's suggestion. It has no effect if the gas
+// price is set explicitly.
+func WithGasPriceOracle(oracle GasPriceOracle) TxOption {
+	return func(opts *txOptions) {
+		opts.gasPriceOracle = oracle
+	}
+}
+
+// WithGasEstimator makes the transactor take the gas limit of a transaction
+// from estimator instead of the node's estimation. It has no effect if the
+// gas limit is set explicitly.
+func WithGasEstimator(estimator GasEstimator) TxOption {
+	return func(opts *txOptions) {
+		opts.gasEstimator = estimator
+	}
+}
+
+// WithNonce makes the transactor use the given nonce instead of the pending
+// nonce of the account.
+func WithNonce(nonce uint64) TxOption {
+	return func(opts *txOptions) {
+		opts.nonce = &nonce
+	}
+}
+
+// Transactor builds transactions for the account of a signer, has them
+// signed and broadcasts them through the client.
+type Transactor struct {
+	client  Client
+	signer  Signer
+	chainID *big.Int
+}
+
+// NewTransactor creates a transactor which signs transactions for the given
+// chain with signer and sends them through client.
+func NewTransactor(client Client, signer Signer, chainID *big.Int) *Transactor {
+	return &Transactor{
+		client:  client,
+		signer:  signer,
+		chainID: chainID,
+	}
+}
+
+// Address returns the account the transactor sends from.
+func (t *Transactor) Address() common.Address {
+	return t.signer.Address()
+}
+
+// Transact builds a transaction from msg, signs and broadcasts it. The sender
+// is always the signer's account; a missing gas price or gas limit is filled
+// in from the node unless the options say otherwise. A nil msg.To creates a
+// contract.
+func (t *Transactor) Transact(ctx context.Context, msg ethereum.CallMsg, opts ...TxOption) (*types.Transaction, error) {
+	options := txOptions{
+		gasPriceOracle: t.client,
+		gasEstimator:   t.client,
+	}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	msg.From = t.Address()
+	var (
+		nonce uint64
+		err   error
+	)
+	if options.nonce != nil {
+		nonce = *options.nonce
+	} else if nonce, err = t.client.PendingNonceAt(ctx, msg.From); err != nil {
+		return nil, err
+	}
+	if msg.GasPrice == nil {
+		msg.GasPrice, err = options.gasPriceOracle.SuggestGasPrice(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if msg.Gas == nil {
+		msg.Gas, err = options.gasEstimator.EstimateGas(ctx, msg)
+		if err != nil {
+			return nil, err
+		}
+	}
+	value := msg.Value
+	if value == nil {
+		value = new(big.Int)
+	}
+
+	var tx *types.Transaction
+	if msg.To == nil {
+		tx = types.NewContractCreation(nonce, value, msg.Gas, msg.GasPrice, msg.Data)
+	} else {
+		tx = types.NewTransaction(nonce, *msg.To, value, msg.Gas, msg.GasPrice, msg.Data)
+	}
+	return t.Send(ctx, tx)
+}
+
+// Send signs the unsigned transaction, verifies that the signature recovers
+// to the signer's account and broadcasts it with SendRawTransaction.
+func (t *Transactor) Send(ctx context.Context, tx *types.Transaction) (*types.Transaction, error) {
+	signed, err := t.signer.SignTx(ctx, tx, t.chainID)
+	if err != nil {
+		return nil, err
+	}
+	if err := verifySignedTx(tx, signed, t.chainID, t.Address()); err != nil {
+		return nil, err
+	}
+	if err := t.client.SendRawTransaction(ctx, signed); err != nil {
+		return nil, err
+	}
+	return signed, nil
+}
@@ -0,0 +1,320 @@
+// Copyright 2017 AMIS Technologies
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rlp"
+	ethrpc "github.com/ethereum/go-ethereum/rpc"
+)
+
+var (
+	// ErrTxRootMismatch is returned if the transactions of a block do not
+	// hash to the transaction root of its header.
+	ErrTxRootMismatch = errors.New("transactions do not match transaction root")
+	// ErrReceiptCountMismatch is returned if the number of receipts differs
+	// from the number of transactions of a block.
+	ErrReceiptCountMismatch = errors.New("receipt count does not match transaction count")
+	// ErrReceiptRootMismatch is returned if the receipts of a block do not
+	// hash to the receipt root of its header.
+	ErrReceiptRootMismatch = errors.New("receipts do not match receipt root")
+	// ErrUncleHashMismatch is returned if the uncles of a block do not hash
+	// to the uncle hash of its header.
+	ErrUncleHashMismatch = errors.New("uncles do not match uncle hash")
+	// ErrHeaderHashMismatch is returned if a header does not hash to the hash
+	// it was requested by or reported with.
+	ErrHeaderHashMismatch = errors.New("header does not match its hash")
+	// ErrParentHashMismatch is returned if a header does not link to the hash
+	// of the header before it.
+	ErrParentHashMismatch = errors.New("header does not link to its parent")
+	// ErrBlockNumberMismatch is returned if the node returns another block
+	// than the requested one.
+	ErrBlockNumberMismatch = errors.New("unexpected block number")
+	// ErrTxHashMismatch is returned if the node returns another transaction
+	// or receipt than the requested one.
+	ErrTxHashMismatch = errors.New("unexpected transaction hash")
+)
+
+// VerificationError is returned if data returned by a node does not match
+// what it commits to. Err is one of the mismatch errors of this package.
+type VerificationError struct {
+	Number *big.Int
+	Want   common.Hash
+	Got    common.Hash
+	Err    error
+}
+
+func (e *VerificationError) Error() string {
+	return fmt.Sprintf("block %v: %v: want %x, got %x", e.Number, e.Err, e.Want, e.Got)
+}
+
+// Unwrap returns the failed check, so that errors.Is matches it.
+func (e *VerificationError) Unwrap() error {
+	return e.Err
+}
+
+// istanbulDigest is the mix digest of Istanbul headers.
+var istanbulDigest = common.HexToHash("0x63746963616c2062797a616e74696e65206661756c7420746f6c6572616e6365")
+
+// istanbulExtraVanity is the length of the vanity prefix of Istanbul extra data.
+const istanbulExtraVanity = 32
+
+type istanbulExtra struct {
+	Validators    []common.Address
+	Seal          []byte
+	CommittedSeal [][]byte
+}
+
+// HeaderHash returns the hash of a header. The hash of Istanbul headers
+// excludes the committed seals, which are only added after the block was
+// proposed.
+func HeaderHash(header *types.Header) common.Hash {
+	if header.MixDigest != istanbulDigest || len(header.Extra) < istanbulExtraVanity {
+		return header.Hash()
+	}
+	var extra istanbulExtra
+	if err := rlp.DecodeBytes(header.Extra[istanbulExtraVanity:], &extra); err != nil {
+		return header.Hash()
+	}
+	extra.CommittedSeal = [][]byte{}
+	payload, err := rlp.EncodeToBytes(&extra)
+	if err != nil {
+		return header.Hash()
+	}
+	filtered := types.CopyHeader(header)
+	filtered.Extra = append(common.CopyBytes(header.Extra[:istanbulExtraVanity]), payload...)
+	return filtered.Hash()
+}
+
+// VerifyHeaderHash checks that header hashes to hash.
+func VerifyHeaderHash(header *types.Header, hash common.Hash) error {
+	if got := HeaderHash(header); got != hash {
+		return &VerificationError{Number: header.Number, Want: hash, Got: got, Err: ErrHeaderHashMismatch}
+	}
+	return nil
+}
+
+// VerifyBlock checks that the transactions and uncles of block match the
+// roots committed to in its header.
+func VerifyBlock(block *types.Block) error {
+	if got := types.DeriveSha(block.Transactions()); got != block.TxHash() {
+		return &VerificationError{Number: block.Number(), Want: block.TxHash(), Got: got, Err: ErrTxRootMismatch}
+	}
+	if got := types.CalcUncleHash(block.Uncles()); got != block.UncleHash() {
+		return &VerificationError{Number: block.Number(), Want: block.UncleHash(), Got: got, Err: ErrUncleHashMismatch}
+	}
+	return nil
+}
+
+// VerifyReceipts checks that receipts belong to the transactions of block
+// and hash to the receipt root of its header.
+func VerifyReceipts(block *types.Block, receipts []*types.Receipt) error {
+	txs := block.Transactions()
+	if len(receipts) != len(txs) {
+		return &VerificationError{Number: block.Number(), Err: ErrReceiptCountMismatch}
+	}
+	for i, receipt := range receipts {
+		if receipt.TxHash != txs[i].Hash() {
+			return &VerificationError{Number: block.Number(), Want: txs[i].Hash(), Got: receipt.TxHash, Err: ErrTxHashMismatch}
+		}
+	}
+	if got := types.DeriveSha(types.Receipts(receipts)); got != block.ReceiptHash() {
+		return &VerificationError{Number: block.Number(), Want: block.ReceiptHash(), Got: got, Err: ErrReceiptRootMismatch}
+	}
+	return nil
+}
+
+// VerifyHeaderChain checks that headers are consecutive and each links to
+// the hash of the one before it.
+func VerifyHeaderChain(headers []*types.Header) error {
+	for i := 1; i < len(headers); i++ {
+		parent, header := headers[i-1], headers[i]
+		if header.Number.Cmp(new(big.Int).Add(parent.Number, common.Big1)) != 0 {
+			return &VerificationError{Number: header.Number, Err: ErrBlockNumberMismatch}
+		}
+		if want := HeaderHash(parent); header.ParentHash != want {
+			return &VerificationError{Number: header.Number, Want: want, Got: header.ParentHash, Err: ErrParentHashMismatch}
+		}
+	}
+	return nil
+}
+
+// VerifyHeaderRange fetches the headers from and to inclusive in a single
+// batch and checks that they form a chain. If to is the latest block, the
+// range may fail on a concurrent reorg.
+func VerifyHeaderRange(ctx context.Context, c Client, from, to uint64) ([]*types.Header, error) {
+	if from > to {
+		return nil, nil
+	}
+	headers := make([]*types.Header, to-from+1)
+	reqs := make([]ethrpc.BatchElem, len(headers))
+	for i := range reqs {
+		reqs[i] = ethrpc.BatchElem{
+			Method: "eth_getBlockByNumber",
+			Args:   []interface{}{hexutil.EncodeUint64(from + uint64(i)), false},
+			Result: &headers[i],
+		}
+	}
+	if err := c.BatchCallContext(ctx, reqs); err != nil {
+		return nil, err
+	}
+	for i, req := range reqs {
+		if req.Error != nil {
+			return nil, req.Error
+		}
+		if headers[i] == nil {
+			return nil, ethereum.NotFound
+		}
+		if headers[i].Number.Uint64() != from+uint64(i) {
+			return nil, &VerificationError{Number: headers[i].Number, Err: ErrBlockNumberMismatch}
+		}
+	}
+	if err := VerifyHeaderChain(headers); err != nil {
+		return nil, err
+	}
+	return headers, nil
+}
+
+// verifyingClient checks the data returned by the node against the hashes
+// and roots it commits to.
+type verifyingClient struct {
+	Client
+}
+
+// NewVerifyingClient wraps c so that blocks, headers, transactions and
+// receipts are verified before they are returned. Data that fails the
+// checks is reported as a *VerificationError.
+func NewVerifyingClient(c Client) Client {
+	return &verifyingClient{Client: c}
+}
+
+func (c *verifyingClient) BlockByHash(ctx context.Context, hash common.Hash) (*types.Block, error) {
+	block, err := c.Client.BlockByHash(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+	if err := VerifyHeaderHash(block.Header(), hash); err != nil {
+		return nil, err
+	}
+	if err := VerifyBlock(block); err != nil {
+		return nil, err
+	}
+	return block, nil
+}
+
+func (c *verifyingClient) BlockByNumber(ctx context.Context, number *big.Int) (*types.Block, error) {
+	block, err := c.Client.BlockByNumber(ctx, number)
+	if err != nil {
+		return nil, err
+	}
+	if err := verifyNumber(block.Header(), number); err != nil {
+		return nil, err
+	}
+	if err := VerifyBlock(block); err != nil {
+		return nil, err
+	}
+	return block, nil
+}
+
+func (c *verifyingClient) HeaderByHash(ctx context.Context, hash common.Hash) (*types.Header, error) {
+	header, err := c.Client.HeaderByHash(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+	if err := VerifyHeaderHash(header, hash); err != nil {
+		return nil, err
+	}
+	return header, nil
+}
+
+func (c *verifyingClient) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	header, err := c.Client.HeaderByNumber(ctx, number)
+	if err != nil {
+		return nil, err
+	}
+	if err := verifyNumber(header, number); err != nil {
+		return nil, err
+	}
+	return header, nil
+}
+
+func (c *verifyingClient) BlockWithReceipts(ctx context.Context, number *big.Int) (*BlockBundle, error) {
+	bundle, err := c.Client.BlockWithReceipts(ctx, number)
+	if err != nil {
+		return nil, err
+	}
+	if err := verifyNumber(bundle.Block.Header(), number); err != nil {
+		return nil, err
+	}
+	if err := VerifyBlock(bundle.Block); err != nil {
+		return nil, err
+	}
+	return bundle, nil
+}
+
+func (c *verifyingClient) BlockWithReceiptsByHash(ctx context.Context, hash common.Hash) (*BlockBundle, error) {
+	bundle, err := c.Client.BlockWithReceiptsByHash(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+	if err := VerifyHeaderHash(bundle.Block.Header(), hash); err != nil {
+		return nil, err
+	}
+	if err := VerifyBlock(bundle.Block); err != nil {
+		return nil, err
+	}
+	return bundle, nil
+}
+
+func (c *verifyingClient) TransactionByHash(ctx context.Context, hash common.Hash) (*types.Transaction, bool, error) {
+	tx, pending, err := c.Client.TransactionByHash(ctx, hash)
+	if err != nil {
+		return nil, false, err
+	}
+	if tx.Hash() != hash {
+		return nil, false, &VerificationError{Want: hash, Got: tx.Hash(), Err: ErrTxHashMismatch}
+	}
+	return tx, pending, nil
+}
+
+func (c *verifyingClient) TransactionReceipt(ctx context.Context, hash common.Hash) (*types.Receipt, error) {
+	receipt, err := c.Client.TransactionReceipt(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+	if receipt.TxHash != hash {
+		return nil, &VerificationError{Want: hash, Got: receipt.TxHash, Err: ErrTxHashMismatch}
+	}
+	return receipt, nil
+}
+
+// verifyNumber checks that header is the one requested by number, unless
+// the latest header was requested.
+func verifyNumber(header *types.Header, number *big.Int) error {
+	if number != nil && header.Number.Cmp(number) != 0 {
+		return &VerificationError{Number: header.Number, Err: ErrBlockNumberMismatch}
+	}
+	return nil
+}
@@ -0,0 +1,146 @@
+// Copyright 2017 AMIS Technologies
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package client
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// Verify that verifyingClient implements the Client interface.
+var (
+	_ = Client(&verifyingClient{})
+)
+
+func TestHeaderHash(t *testing.T) {
+	istanbulHeader := func(committed [][]byte) *types.Header {
+		extra, err := rlp.EncodeToBytes(&istanbulExtra{
+			Validators:    []common.Address{common.HexToAddress("0x00000000000000000000000000000000000000aa")},
+			Seal:          []byte{1, 2, 3},
+			CommittedSeal: committed,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return &types.Header{
+			Number:     big.NewInt(1),
+			Time:       big.NewInt(1500000000),
+			Difficulty: common.Big1,
+			GasLimit:   big.NewInt(4700000),
+			GasUsed:    new(big.Int),
+			MixDigest:  istanbulDigest,
+			Extra:      append(make([]byte, istanbulExtraVanity), extra...),
+		}
+	}
+	unsealed := istanbulHeader([][]byte{})
+	sealed := istanbulHeader([][]byte{{4, 5, 6}, {7, 8, 9}})
+
+	if HeaderHash(unsealed) != unsealed.Hash() {
+		t.Error("hash of header without committed seals changed")
+	}
+	if HeaderHash(sealed) != HeaderHash(unsealed) {
+		t.Error("istanbul hash depends on committed seals")
+	}
+	if sealed.Hash() == unsealed.Hash() {
+		t.Error("plain header hash ignores extra data")
+	}
+
+	plain := types.CopyHeader(sealed)
+	plain.MixDigest = common.Hash{}
+	if HeaderHash(plain) != plain.Hash() {
+		t.Error("unexpected hash of non istanbul header")
+	}
+}
+
+func TestVerifyBlocks(t *testing.T) {
+	chain := &FakeChain{}
+	newTestBlocks(t, chain, 20)
+
+	for _, block := range chain.blocks {
+		if err := VerifyBlock(block); err != nil {
+			t.Fatalf("block %v: %v", block.Number(), err)
+		}
+	}
+	headers := make([]*types.Header, len(chain.blocks))
+	for i, block := range chain.blocks {
+		headers[i] = block.Header()
+	}
+	if err := VerifyHeaderChain(headers); err != nil {
+		t.Fatal(err)
+	}
+
+	// Swap the transactions of two blocks.
+	forged := types.NewBlockWithHeader(chain.blocks[5].Header()).WithBody(chain.blocks[4].Transactions(), nil)
+	err := VerifyBlock(forged)
+	if !errors.Is(err, ErrTxRootMismatch) {
+		t.Errorf("unexpected error: %v", err)
+	}
+	var verr *VerificationError
+	if !errors.As(err, &verr) || verr.Number.Int64() != 5 || verr.Want != forged.TxHash() {
+		t.Errorf("unexpected verification error: %+v", verr)
+	}
+
+	// Break the chain.
+	broken := types.CopyHeader(headers[10])
+	broken.ParentHash = common.Hash{1}
+	headers[10] = broken
+	if err := VerifyHeaderChain(headers); !errors.Is(err, ErrParentHashMismatch) {
+		t.Errorf("unexpected error: %v", err)
+	}
+	headers[10] = chain.blocks[10].Header()
+	if err := VerifyHeaderChain(append(headers[:5], headers[6:]...)); !errors.Is(err, ErrBlockNumberMismatch) {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestVerifyingClient(t *testing.T) {
+	chain := &FakeChain{}
+	newTestBlocks(t, chain, 20)
+	client := NewVerifyingClient(newTestNodeClient(t, chain))
+	ctx := context.Background()
+
+	if _, err := VerifyHeaderRange(ctx, client, 3, 17); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.BlockByHash(ctx, chain.blocks[8].Hash()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.BlockWithReceipts(ctx, big.NewInt(8)); err != nil {
+		t.Fatal(err)
+	}
+
+	// The node serves block 8 with the transactions of block 7.
+	chain.blocks[8] = types.NewBlockWithHeader(chain.blocks[8].Header()).WithBody(chain.blocks[7].Transactions(), nil)
+	if _, err := client.BlockByNumber(ctx, big.NewInt(8)); !errors.Is(err, ErrTxRootMismatch) {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	// The node serves a block under the wrong number.
+	chain.blocks[9] = chain.blocks[10]
+	if _, err := client.HeaderByNumber(ctx, big.NewInt(9)); !errors.Is(err, ErrBlockNumberMismatch) {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if _, err := VerifyHeaderRange(ctx, client, 3, 17); !errors.Is(err, ErrBlockNumberMismatch) {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,64 @@
+// Copyright 2017 AMIS Technologies
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package client
+
+import (
+	"context"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// WaitMined polls the receipt of a transaction until it has been mined or
+// the context is done.
+func WaitMined(ctx context.Context, client Client, txHash common.Hash) (*types.Receipt, error) {
+	_, receipt, err := waitAnyMined(ctx, client, []common.Hash{txHash})
+	return receipt, err
+}
+
+// waitAnyMined polls the receipts of the given transactions until one of them
+// has been mined or the context is done, and returns the index of the mined
+// transaction together with its receipt.
+func waitAnyMined(ctx context.Context, client Client, hashes []common.Hash) (int, *types.Receipt, error) {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		for i, hash := range hashes {
+			receipt, err := client.TransactionReceipt(ctx, hash)
+			if err == nil && receipt != nil {
+				return i, receipt, nil
+			}
+			if err != nil && err != ethereum.NotFound {
+				log.Debug("Failed to get transaction receipt", "hash", hash.Hex(), "err", err)
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return -1, nil, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// receiptFailed reports whether a post-byzantium receipt reports a failed
+// execution.
+func receiptFailed(receipt *types.Receipt) bool {
+	return len(receipt.PostState) == 0 && receipt.Status == types.ReceiptStatusFailed
+}
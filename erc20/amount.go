@@ -0,0 +1,76 @@
+// Copyright 2017 AMIS Technologies
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package erc20
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// FormatAmount renders an amount of base units as a decimal string of whole
+// tokens, e.g. 1500000 with 6 decimals as "1.5".
+func FormatAmount(amount *big.Int, decimals uint8) string {
+	sign := ""
+	if amount.Sign() < 0 {
+		sign = "-"
+	}
+	digits := new(big.Int).Abs(amount).String()
+	if decimals == 0 {
+		return sign + digits
+	}
+	if len(digits) <= int(decimals) {
+		digits = strings.Repeat("0", int(decimals)-len(digits)+1) + digits
+	}
+	whole, frac := digits[:len(digits)-int(decimals)], strings.TrimRight(digits[len(digits)-int(decimals):], "0")
+	if frac == "" {
+		return sign + whole
+	}
+	return sign + whole + "." + frac
+}
+
+// ParseAmount parses a decimal string of whole tokens into base units, e.g.
+// "1.5" with 6 decimals as 1500000. More fractional digits than decimals
+// is an error.
+func ParseAmount(amount string, decimals uint8) (*big.Int, error) {
+	s := strings.TrimSpace(amount)
+	neg := strings.HasPrefix(s, "-")
+	s = strings.TrimPrefix(s, "-")
+
+	whole, frac := s, ""
+	if i := strings.Index(s, "."); i >= 0 {
+		whole, frac = s[:i], s[i+1:]
+	}
+	if whole == "" && frac == "" {
+		return nil, fmt.Errorf("invalid amount %q", amount)
+	}
+	if len(frac) > int(decimals) {
+		return nil, fmt.Errorf("amount %q exceeds %d decimals", amount, decimals)
+	}
+	digits := whole + frac + strings.Repeat("0", int(decimals)-len(frac))
+	if strings.ContainsAny(digits, "+-") {
+		return nil, fmt.Errorf("invalid amount %q", amount)
+	}
+	value, ok := new(big.Int).SetString(digits, 10)
+	if !ok {
+		return nil, fmt.Errorf("invalid amount %q", amount)
+	}
+	if neg {
+		value.Neg(value)
+	}
+	return value, nil
+}
@@ -0,0 +1,70 @@
+// Copyright 2017 AMIS Technologies
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package erc20
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	ethClient "github.com/getamis/eth-client/client"
+)
+
+type Token interface {
+	Address() common.Address
+
+	// reads, a nil blockNumber reads at the latest block
+	Name(ctx context.Context) (string, error)
+	Symbol(ctx context.Context) (string, error)
+	Decimals(ctx context.Context) (uint8, error)
+	TotalSupply(ctx context.Context, blockNumber *big.Int) (*big.Int, error)
+	BalanceOf(ctx context.Context, owner common.Address, blockNumber *big.Int) (*big.Int, error)
+	Allowance(ctx context.Context, owner, spender common.Address, blockNumber *big.Int) (*big.Int, error)
+
+	// sends
+	Transfer(ctx context.Context, transactor *ethClient.Transactor, to common.Address, value *big.Int) (*types.Transaction, error)
+	Approve(ctx context.Context, transactor *ethClient.Transactor, spender common.Address, value *big.Int) (*types.Transaction, error)
+	TransferFrom(ctx context.Context, transactor *ethClient.Transactor, from, to common.Address, value *big.Int) (*types.Transaction, error)
+
+	// events, nil address lists match any address
+	FilterTransfer(ctx context.Context, fromBlock, toBlock *big.Int, from, to []common.Address) ([]*TransferEvent, error)
+	SubscribeTransfer(ctx context.Context, ch chan<- *TransferEvent, from, to []common.Address) (ethereum.Subscription, error)
+	FilterApproval(ctx context.Context, fromBlock, toBlock *big.Int, owner, spender []common.Address) ([]*ApprovalEvent, error)
+	SubscribeApproval(ctx context.Context, ch chan<- *ApprovalEvent, owner, spender []common.Address) (ethereum.Subscription, error)
+
+	// amounts
+	FormatAmount(ctx context.Context, amount *big.Int) (string, error)
+	ParseAmount(ctx context.Context, amount string) (*big.Int, error)
+}
+
+// TransferEvent is a Transfer event emitted by a token.
+type TransferEvent struct {
+	From  common.Address
+	To    common.Address
+	Value *big.Int
+	Raw   types.Log
+}
+
+// ApprovalEvent is an Approval event emitted by a token.
+type ApprovalEvent struct {
+	Owner   common.Address
+	Spender common.Address
+	Value   *big.Int
+	Raw     types.Log
+}
@@ -0,0 +1,223 @@
+// Copyright 2017 AMIS Technologies
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package erc20
+
+import (
+	"bytes"
+	"context"
+	"math/big"
+	"sync"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	ethClient "github.com/getamis/eth-client/client"
+)
+
+var (
+	parsedABI        = ethClient.MustParseABI(ABI)
+	parsedBytes32ABI = ethClient.MustParseABI(bytes32ABI)
+)
+
+// token defines typed wrappers for an ERC20 token contract.
+type token struct {
+	client   ethClient.Client
+	contract *ethClient.Contract
+	decoder  *ethClient.EventDecoder
+
+	mu       sync.Mutex
+	decimals *uint8
+}
+
+// NewToken creates a handle to the ERC20 token at address.
+func NewToken(client ethClient.Client, address common.Address) Token {
+	decoder := ethClient.NewEventDecoder()
+	decoder.RegisterAddress(address, parsedABI)
+	return &token{
+		client:   client,
+		contract: ethClient.NewContractWithABI(client, parsedABI, address),
+		decoder:  decoder,
+	}
+}
+
+// Address returns the address of the token contract.
+func (t *token) Address() common.Address {
+	return t.contract.Address()
+}
+
+// Name returns the name of the token. Tokens returning bytes32 are supported.
+func (t *token) Name(ctx context.Context) (string, error) {
+	return t.text(ctx, "name")
+}
+
+// Symbol returns the symbol of the token. Tokens returning bytes32 are supported.
+func (t *token) Symbol(ctx context.Context) (string, error) {
+	return t.text(ctx, "symbol")
+}
+
+// Decimals returns the number of decimals of the token. The value is cached
+// after the first successful call.
+func (t *token) Decimals(ctx context.Context) (uint8, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.decimals != nil {
+		return *t.decimals, nil
+	}
+	var decimals uint8
+	if err := t.contract.Call(ctx, nil, &decimals, "decimals"); err != nil {
+		return 0, err
+	}
+	t.decimals = &decimals
+	return decimals, nil
+}
+
+// TotalSupply returns the total supply of the token at the given block.
+func (t *token) TotalSupply(ctx context.Context, blockNumber *big.Int) (*big.Int, error) {
+	var supply *big.Int
+	err := t.contract.Call(ctx, blockNumber, &supply, "totalSupply")
+	return supply, err
+}
+
+// BalanceOf returns the token balance of owner at the given block.
+func (t *token) BalanceOf(ctx context.Context, owner common.Address, blockNumber *big.Int) (*big.Int, error) {
+	var balance *big.Int
+	err := t.contract.Call(ctx, blockNumber, &balance, "balanceOf", owner)
+	return balance, err
+}
+
+// Allowance returns the amount spender may transfer on behalf of owner at the
+// given block.
+func (t *token) Allowance(ctx context.Context, owner, spender common.Address, blockNumber *big.Int) (*big.Int, error) {
+	var allowance *big.Int
+	err := t.contract.Call(ctx, blockNumber, &allowance, "allowance", owner, spender)
+	return allowance, err
+}
+
+// Transfer sends value tokens from the transactor's account to to.
+func (t *token) Transfer(ctx context.Context, transactor *ethClient.Transactor, to common.Address, value *big.Int) (*types.Transaction, error) {
+	return t.contract.Transact(ctx, transactor, nil, "transfer", []interface{}{to, value})
+}
+
+// Approve allows spender to transfer up to value tokens from the transactor's
+// account.
+func (t *token) Approve(ctx context.Context, transactor *ethClient.Transactor, spender common.Address, value *big.Int) (*types.Transaction, error) {
+	return t.contract.Transact(ctx, transactor, nil, "approve", []interface{}{spender, value})
+}
+
+// TransferFrom sends value tokens from from to to, using the allowance granted
+// to the transactor's account.
+func (t *token) TransferFrom(ctx context.Context, transactor *ethClient.Transactor, from, to common.Address, value *big.Int) (*types.Transaction, error) {
+	return t.contract.Transact(ctx, transactor, nil, "transferFrom", []interface{}{from, to, value})
+}
+
+// FilterTransfer returns the Transfer events in the given block range.
+// Malformed logs are skipped.
+func (t *token) FilterTransfer(ctx context.Context, fromBlock, toBlock *big.Int, from, to []common.Address) ([]*TransferEvent, error) {
+	events := []*TransferEvent{}
+	err := ethClient.FilterEvents(ctx, t.client, t.query("Transfer", fromBlock, toBlock, from, to), t.decodeTransfer, &events)
+	return events, err
+}
+
+// SubscribeTransfer subscribes to new Transfer events. Malformed logs are
+// skipped.
+func (t *token) SubscribeTransfer(ctx context.Context, ch chan<- *TransferEvent, from, to []common.Address) (ethereum.Subscription, error) {
+	return ethClient.SubscribeEvents(ctx, t.client, t.query("Transfer", nil, nil, from, to), t.decodeTransfer, ch)
+}
+
+// FilterApproval returns the Approval events in the given block range.
+// Malformed logs are skipped.
+func (t *token) FilterApproval(ctx context.Context, fromBlock, toBlock *big.Int, owner, spender []common.Address) ([]*ApprovalEvent, error) {
+	events := []*ApprovalEvent{}
+	err := ethClient.FilterEvents(ctx, t.client, t.query("Approval", fromBlock, toBlock, owner, spender), t.decodeApproval, &events)
+	return events, err
+}
+
+// SubscribeApproval subscribes to new Approval events. Malformed logs are
+// skipped.
+func (t *token) SubscribeApproval(ctx context.Context, ch chan<- *ApprovalEvent, owner, spender []common.Address) (ethereum.Subscription, error) {
+	return ethClient.SubscribeEvents(ctx, t.client, t.query("Approval", nil, nil, owner, spender), t.decodeApproval, ch)
+}
+
+// FormatAmount renders an amount of base units in whole tokens, according to
+// the token's decimals.
+func (t *token) FormatAmount(ctx context.Context, amount *big.Int) (string, error) {
+	decimals, err := t.Decimals(ctx)
+	if err != nil {
+		return "", err
+	}
+	return FormatAmount(amount, decimals), nil
+}
+
+// ParseAmount parses an amount of whole tokens into base units, according to
+// the token's decimals.
+func (t *token) ParseAmount(ctx context.Context, amount string) (*big.Int, error) {
+	decimals, err := t.Decimals(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return ParseAmount(amount, decimals)
+}
+
+func (t *token) text(ctx context.Context, method string) (string, error) {
+	output, err := t.contract.CallOutput(ctx, nil, method)
+	if err != nil {
+		return "", err
+	}
+	// A string output holds at least an offset and a length word. A single
+	// word is the bytes32 some early tokens return instead.
+	if len(output) != 32 {
+		var s string
+		err := parsedABI.Unpack(&s, method, output)
+		return s, err
+	}
+	var b [32]byte
+	if err := parsedBytes32ABI.Unpack(&b, method, output); err != nil {
+		return "", err
+	}
+	return string(bytes.TrimRight(b[:], "\x00")), nil
+}
+
+func (t *token) query(name string, fromBlock, toBlock *big.Int, first, second []common.Address) ethereum.FilterQuery {
+	return ethClient.EventQuery(t.Address(), parsedABI.Events[name], fromBlock, toBlock, ethClient.AddressTopics(first), ethClient.AddressTopics(second))
+}
+
+func (t *token) decodeTransfer(l types.Log) (*TransferEvent, error) {
+	ev, err := t.decoder.Decode(l)
+	if err != nil {
+		return nil, err
+	}
+	return &TransferEvent{
+		From:  ev.Indexed["from"].(common.Address),
+		To:    ev.Indexed["to"].(common.Address),
+		Value: ev.NonIndexed["value"].(*big.Int),
+		Raw:   l,
+	}, nil
+}
+
+func (t *token) decodeApproval(l types.Log) (*ApprovalEvent, error) {
+	ev, err := t.decoder.Decode(l)
+	if err != nil {
+		return nil, err
+	}
+	return &ApprovalEvent{
+		Owner:   ev.Indexed["owner"].(common.Address),
+		Spender: ev.Indexed["spender"].(common.Address),
+		Value:   ev.NonIndexed["value"].(*big.Int),
+		Raw:     l,
+	}, nil
+}
@@ -0,0 +1,226 @@
+// Copyright 2017 AMIS Technologies
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package erc20
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+	ethrpc "github.com/ethereum/go-ethereum/rpc"
+	ethClient "github.com/getamis/eth-client/client"
+)
+
+// Verify that token implements the Token interface.
+var (
+	_ = Token(&token{})
+)
+
+func TestAmount(t *testing.T) {
+	tests := []struct {
+		amount   string
+		decimals uint8
+		value    int64
+		format   string
+	}{
+		{"1.5", 6, 1500000, "1.5"},
+		{"0.000001", 6, 1, "0.000001"},
+		{"100", 0, 100, "100"},
+		{"-2.50", 2, -250, "-2.5"},
+		{".5", 1, 5, "0.5"},
+	}
+	for i, test := range tests {
+		value, err := ParseAmount(test.amount, test.decimals)
+		if err != nil {
+			t.Errorf("test %d: unexpected error: %v", i, err)
+			continue
+		}
+		if value.Int64() != test.value {
+			t.Errorf("test %d: value mismatch: have %v, want %v", i, value, test.value)
+		}
+		if format := FormatAmount(big.NewInt(test.value), test.decimals); format != test.format {
+			t.Errorf("test %d: format mismatch: have %s, want %s", i, format, test.format)
+		}
+	}
+	for _, amount := range []string{"", "1.234", "1.2.3", "abc", "1.-2"} {
+		if _, err := ParseAmount(amount, 2); err == nil {
+			t.Errorf("expected an error for %q", amount)
+		}
+	}
+}
+
+// FakeToken is a stand-in for a node serving a token contract.
+type FakeToken struct {
+	// results maps hex encoded call data to canned call results, other
+	// calls revert.
+	results map[string]hexutil.Bytes
+	logs    []types.Log
+}
+
+func (n *FakeToken) Call(args map[string]interface{}, block string) (hexutil.Bytes, error) {
+	if result, ok := n.results[args["data"].(string)]; ok {
+		return result, nil
+	}
+	return nil, errors.New("execution reverted")
+}
+
+func (n *FakeToken) GetCode(account common.Address, block string) hexutil.Bytes {
+	return hexutil.Bytes{0x60}
+}
+
+func (n *FakeToken) GetLogs(args map[string]interface{}) []types.Log {
+	return n.logs
+}
+
+// logClient streams canned logs to subscribers.
+type logClient struct {
+	ethClient.Client
+	logs []types.Log
+}
+
+func (c *logClient) SubscribeFilterLogs(ctx context.Context, q ethereum.FilterQuery, ch chan<- types.Log) (ethereum.Subscription, error) {
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		for _, l := range c.logs {
+			select {
+			case ch <- l:
+			case <-quit:
+				return nil
+			}
+		}
+		<-quit
+		return nil
+	}), nil
+}
+
+func newTestClient(t *testing.T, node *FakeToken) ethClient.Client {
+	srv := ethrpc.NewServer()
+	if err := srv.RegisterName("eth", node); err != nil {
+		t.Fatal(err)
+	}
+	return ethClient.NewClient(ethrpc.DialInProc(srv))
+}
+
+func (n *FakeToken) setResult(method string, output []byte) {
+	input, _ := parsedABI.Pack(method)
+	n.results[hexutil.Encode(input)] = output
+}
+
+func packString(s string) []byte {
+	data := common.LeftPadBytes(big.NewInt(32).Bytes(), 32)
+	data = append(data, common.LeftPadBytes(big.NewInt(int64(len(s))).Bytes(), 32)...)
+	return append(data, common.RightPadBytes([]byte(s), (len(s)+31)/32*32)...)
+}
+
+func TestTokenMetadata(t *testing.T) {
+	node := &FakeToken{results: make(map[string]hexutil.Bytes)}
+	node.setResult("name", packString("A"))
+	node.setResult("symbol", common.RightPadBytes([]byte("MKR"), 32))
+	node.setResult("decimals", common.LeftPadBytes([]byte{18}, 32))
+	c := newTestClient(t, node)
+	defer c.Close()
+	ctx := context.Background()
+
+	token := NewToken(c, common.HexToAddress("0x00000000000000000000000000000000000000cc"))
+	if name, err := token.Name(ctx); err != nil || name != "A" {
+		t.Errorf("name mismatch: have %q (%v), want %q", name, err, "A")
+	}
+	if symbol, err := token.Symbol(ctx); err != nil || symbol != "MKR" {
+		t.Errorf("symbol mismatch: have %q (%v), want %q", symbol, err, "MKR")
+	}
+
+	// Failing calls are not retried as bytes32.
+	name, _ := parsedABI.Pack("name")
+	delete(node.results, hexutil.Encode(name))
+	if _, err := token.Name(ctx); err == nil {
+		t.Error("expected an error for a reverted call")
+	}
+
+	// Decimals are cached.
+	for i := 0; i < 2; i++ {
+		if decimals, err := token.Decimals(ctx); err != nil || decimals != 18 {
+			t.Errorf("decimals mismatch: have %d (%v), want 18", decimals, err)
+		}
+		node.results = nil
+	}
+}
+
+func TestTokenTransferEvents(t *testing.T) {
+	address := common.HexToAddress("0x00000000000000000000000000000000000000cc")
+	from := common.HexToAddress("0x00000000000000000000000000000000000000aa")
+	to := common.HexToAddress("0x00000000000000000000000000000000000000bb")
+	transfer := func(value int64, topics ...common.Hash) types.Log {
+		return types.Log{
+			Address: address,
+			Topics:  append([]common.Hash{parsedABI.Events["Transfer"].Id()}, topics...),
+			Data:    common.LeftPadBytes(big.NewInt(value).Bytes(), 32),
+		}
+	}
+	// The second log lacks the recipient topic, as a token with a
+	// non-standard event would emit it.
+	logs := []types.Log{
+		transfer(1, from.Hash(), to.Hash()),
+		transfer(2, from.Hash()),
+		transfer(3, to.Hash(), from.Hash()),
+	}
+	node := &FakeToken{logs: logs}
+	c := newTestClient(t, node)
+	defer c.Close()
+
+	check := func(events []*TransferEvent) {
+		if len(events) != 2 {
+			t.Fatalf("event count mismatch: have %d, want 2", len(events))
+		}
+		if events[0].From != from || events[0].To != to || events[0].Value.Int64() != 1 {
+			t.Errorf("event 0 mismatch: have %+v", events[0])
+		}
+		if events[1].From != to || events[1].To != from || events[1].Value.Int64() != 3 {
+			t.Errorf("event 1 mismatch: have %+v", events[1])
+		}
+	}
+
+	events, err := NewToken(c, address).FilterTransfer(context.Background(), nil, nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	check(events)
+
+	ch := make(chan *TransferEvent)
+	sub, err := NewToken(&logClient{Client: c, logs: logs}, address).SubscribeTransfer(context.Background(), ch, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sub.Unsubscribe()
+	events = nil
+	for len(events) < 2 {
+		select {
+		case ev := <-ch:
+			events = append(events, ev)
+		case err := <-sub.Err():
+			t.Fatalf("subscription ended: %v", err)
+		case <-time.After(time.Second):
+			t.Fatal("timed out waiting for events")
+		}
+	}
+	check(events)
+}
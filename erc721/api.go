@@ -0,0 +1,86 @@
+// Copyright 2017 AMIS Technologies
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package erc721
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	ethClient "github.com/getamis/eth-client/client"
+)
+
+type Token interface {
+	Address() common.Address
+
+	// reads, a nil blockNumber reads at the latest block
+	BalanceOf(ctx context.Context, owner common.Address, blockNumber *big.Int) (*big.Int, error)
+	OwnerOf(ctx context.Context, tokenID *big.Int, blockNumber *big.Int) (common.Address, error)
+	TokenURI(ctx context.Context, tokenID *big.Int) (string, error)
+	GetApproved(ctx context.Context, tokenID *big.Int, blockNumber *big.Int) (common.Address, error)
+	IsApprovedForAll(ctx context.Context, owner, operator common.Address, blockNumber *big.Int) (bool, error)
+
+	// sends
+	Approve(ctx context.Context, transactor *ethClient.Transactor, to common.Address, tokenID *big.Int) (*types.Transaction, error)
+	SetApprovalForAll(ctx context.Context, transactor *ethClient.Transactor, operator common.Address, approved bool) (*types.Transaction, error)
+	TransferFrom(ctx context.Context, transactor *ethClient.Transactor, from, to common.Address, tokenID *big.Int) (*types.Transaction, error)
+	SafeTransferFrom(ctx context.Context, transactor *ethClient.Transactor, from, to common.Address, tokenID *big.Int) (*types.Transaction, error)
+
+	// events, nil address lists match any address
+	FilterTransfer(ctx context.Context, fromBlock, toBlock *big.Int, from, to []common.Address) ([]*TransferEvent, error)
+	SubscribeTransfer(ctx context.Context, ch chan<- *TransferEvent, from, to []common.Address) (ethereum.Subscription, error)
+	FilterApproval(ctx context.Context, fromBlock, toBlock *big.Int, owner, approved []common.Address) ([]*ApprovalEvent, error)
+	SubscribeApproval(ctx context.Context, ch chan<- *ApprovalEvent, owner, approved []common.Address) (ethereum.Subscription, error)
+	FilterApprovalForAll(ctx context.Context, fromBlock, toBlock *big.Int, owner, operator []common.Address) ([]*ApprovalForAllEvent, error)
+	SubscribeApprovalForAll(ctx context.Context, ch chan<- *ApprovalForAllEvent, owner, operator []common.Address) (ethereum.Subscription, error)
+
+	// collection
+	DeploymentBlock(ctx context.Context) (*big.Int, error)
+	Owners(ctx context.Context, fromBlock, toBlock *big.Int) ([]*Ownership, error)
+}
+
+// TransferEvent is a Transfer event emitted by a token.
+type TransferEvent struct {
+	From    common.Address
+	To      common.Address
+	TokenID *big.Int
+	Raw     types.Log
+}
+
+// ApprovalEvent is an Approval event emitted by a token.
+type ApprovalEvent struct {
+	Owner    common.Address
+	Approved common.Address
+	TokenID  *big.Int
+	Raw      types.Log
+}
+
+// ApprovalForAllEvent is an ApprovalForAll event emitted by a token.
+type ApprovalForAllEvent struct {
+	Owner    common.Address
+	Operator common.Address
+	Approved bool
+	Raw      types.Log
+}
+
+// Ownership is the owner of a single token of a collection.
+type Ownership struct {
+	TokenID *big.Int
+	Owner   common.Address
+}
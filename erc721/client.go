@@ -0,0 +1,280 @@
+// Copyright 2017 AMIS Technologies
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package erc721
+
+import (
+	"context"
+	"math/big"
+	"sort"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	ethClient "github.com/getamis/eth-client/client"
+)
+
+var parsedABI = ethClient.MustParseABI(ABI)
+
+// token defines typed wrappers for an ERC721 token contract.
+type token struct {
+	client   ethClient.Client
+	contract *ethClient.Contract
+	decoder  *ethClient.EventDecoder
+}
+
+// NewToken creates a handle to the ERC721 token at address.
+func NewToken(client ethClient.Client, address common.Address) Token {
+	decoder := ethClient.NewEventDecoder()
+	decoder.RegisterAddress(address, parsedABI)
+	return &token{
+		client:   client,
+		contract: ethClient.NewContractWithABI(client, parsedABI, address),
+		decoder:  decoder,
+	}
+}
+
+// Address returns the address of the token contract.
+func (t *token) Address() common.Address {
+	return t.contract.Address()
+}
+
+// BalanceOf returns the number of tokens owned by owner at the given block.
+func (t *token) BalanceOf(ctx context.Context, owner common.Address, blockNumber *big.Int) (*big.Int, error) {
+	var balance *big.Int
+	err := t.contract.Call(ctx, blockNumber, &balance, "balanceOf", owner)
+	return balance, err
+}
+
+// OwnerOf returns the owner of a token at the given block.
+func (t *token) OwnerOf(ctx context.Context, tokenID *big.Int, blockNumber *big.Int) (common.Address, error) {
+	var owner common.Address
+	err := t.contract.Call(ctx, blockNumber, &owner, "ownerOf", tokenID)
+	return owner, err
+}
+
+// TokenURI returns the metadata URI of a token.
+func (t *token) TokenURI(ctx context.Context, tokenID *big.Int) (string, error) {
+	var uri string
+	err := t.contract.Call(ctx, nil, &uri, "tokenURI", tokenID)
+	return uri, err
+}
+
+// GetApproved returns the account approved to transfer a token at the given
+// block.
+func (t *token) GetApproved(ctx context.Context, tokenID *big.Int, blockNumber *big.Int) (common.Address, error) {
+	var approved common.Address
+	err := t.contract.Call(ctx, blockNumber, &approved, "getApproved", tokenID)
+	return approved, err
+}
+
+// IsApprovedForAll reports whether operator may transfer all tokens of owner
+// at the given block.
+func (t *token) IsApprovedForAll(ctx context.Context, owner, operator common.Address, blockNumber *big.Int) (bool, error) {
+	var approved bool
+	err := t.contract.Call(ctx, blockNumber, &approved, "isApprovedForAll", owner, operator)
+	return approved, err
+}
+
+// Approve allows to to transfer a token of the transactor's account.
+func (t *token) Approve(ctx context.Context, transactor *ethClient.Transactor, to common.Address, tokenID *big.Int) (*types.Transaction, error) {
+	return t.contract.Transact(ctx, transactor, nil, "approve", []interface{}{to, tokenID})
+}
+
+// SetApprovalForAll allows or forbids operator to transfer all tokens of the
+// transactor's account.
+func (t *token) SetApprovalForAll(ctx context.Context, transactor *ethClient.Transactor, operator common.Address, approved bool) (*types.Transaction, error) {
+	return t.contract.Transact(ctx, transactor, nil, "setApprovalForAll", []interface{}{operator, approved})
+}
+
+// TransferFrom transfers a token from from to to.
+func (t *token) TransferFrom(ctx context.Context, transactor *ethClient.Transactor, from, to common.Address, tokenID *big.Int) (*types.Transaction, error) {
+	return t.contract.Transact(ctx, transactor, nil, "transferFrom", []interface{}{from, to, tokenID})
+}
+
+// SafeTransferFrom transfers a token from from to to, checking that a
+// receiving contract accepts ERC721 tokens.
+func (t *token) SafeTransferFrom(ctx context.Context, transactor *ethClient.Transactor, from, to common.Address, tokenID *big.Int) (*types.Transaction, error) {
+	return t.contract.Transact(ctx, transactor, nil, "safeTransferFrom", []interface{}{from, to, tokenID})
+}
+
+// FilterTransfer returns the Transfer events in the given block range.
+// Malformed logs are skipped.
+func (t *token) FilterTransfer(ctx context.Context, fromBlock, toBlock *big.Int, from, to []common.Address) ([]*TransferEvent, error) {
+	events := []*TransferEvent{}
+	err := ethClient.FilterEvents(ctx, t.client, t.query("Transfer", fromBlock, toBlock, from, to), t.decodeTransfer, &events)
+	return events, err
+}
+
+// SubscribeTransfer subscribes to new Transfer events. Malformed logs are
+// skipped.
+func (t *token) SubscribeTransfer(ctx context.Context, ch chan<- *TransferEvent, from, to []common.Address) (ethereum.Subscription, error) {
+	return ethClient.SubscribeEvents(ctx, t.client, t.query("Transfer", nil, nil, from, to), t.decodeTransfer, ch)
+}
+
+// FilterApproval returns the Approval events in the given block range.
+// Malformed logs are skipped.
+func (t *token) FilterApproval(ctx context.Context, fromBlock, toBlock *big.Int, owner, approved []common.Address) ([]*ApprovalEvent, error) {
+	events := []*ApprovalEvent{}
+	err := ethClient.FilterEvents(ctx, t.client, t.query("Approval", fromBlock, toBlock, owner, approved), t.decodeApproval, &events)
+	return events, err
+}
+
+// SubscribeApproval subscribes to new Approval events. Malformed logs are
+// skipped.
+func (t *token) SubscribeApproval(ctx context.Context, ch chan<- *ApprovalEvent, owner, approved []common.Address) (ethereum.Subscription, error) {
+	return ethClient.SubscribeEvents(ctx, t.client, t.query("Approval", nil, nil, owner, approved), t.decodeApproval, ch)
+}
+
+// FilterApprovalForAll returns the ApprovalForAll events in the given block
+// range. Malformed logs are skipped.
+func (t *token) FilterApprovalForAll(ctx context.Context, fromBlock, toBlock *big.Int, owner, operator []common.Address) ([]*ApprovalForAllEvent, error) {
+	events := []*ApprovalForAllEvent{}
+	err := ethClient.FilterEvents(ctx, t.client, t.query("ApprovalForAll", fromBlock, toBlock, owner, operator), t.decodeApprovalForAll, &events)
+	return events, err
+}
+
+// SubscribeApprovalForAll subscribes to new ApprovalForAll events. Malformed
+// logs are skipped.
+func (t *token) SubscribeApprovalForAll(ctx context.Context, ch chan<- *ApprovalForAllEvent, owner, operator []common.Address) (ethereum.Subscription, error) {
+	return ethClient.SubscribeEvents(ctx, t.client, t.query("ApprovalForAll", nil, nil, owner, operator), t.decodeApprovalForAll, ch)
+}
+
+// DeploymentBlock binary searches the block in which the token contract was
+// deployed. It needs a node which keeps the historical state.
+func (t *token) DeploymentBlock(ctx context.Context) (*big.Int, error) {
+	head, err := t.client.BlockNumber(ctx)
+	if err != nil {
+		return nil, err
+	}
+	code, err := t.client.CodeAt(ctx, t.Address(), head)
+	if err != nil {
+		return nil, err
+	}
+	if len(code) == 0 {
+		return nil, ethClient.ErrNoCode
+	}
+
+	lo, hi := new(big.Int), new(big.Int).Set(head)
+	for lo.Cmp(hi) < 0 {
+		mid := new(big.Int).Add(lo, hi)
+		mid.Rsh(mid, 1)
+		code, err := t.client.CodeAt(ctx, t.Address(), mid)
+		if err != nil {
+			return nil, err
+		}
+		if len(code) > 0 {
+			hi = mid
+		} else {
+			lo = mid.Add(mid, common.Big1)
+		}
+	}
+	return hi, nil
+}
+
+// Owners rebuilds the current ownership of the collection by replaying the
+// Transfer events in the given block range, fetched in chunks the node can
+// serve. A nil fromBlock starts at the deployment of the contract and a nil
+// toBlock ends at the latest block. Burned tokens are left out and the result
+// is ordered by token ID.
+func (t *token) Owners(ctx context.Context, fromBlock, toBlock *big.Int) ([]*Ownership, error) {
+	if fromBlock == nil {
+		var err error
+		if fromBlock, err = t.DeploymentBlock(ctx); err != nil {
+			return nil, err
+		}
+	}
+	it := ethClient.NewLogFetcher(t.client, ethClient.LogFetcherConfig{}).Iterator(ctx, t.query("Transfer", fromBlock, toBlock, nil, nil))
+	defer it.Close()
+
+	owners := make(map[string]*Ownership)
+	for it.Next() {
+		l := it.Log()
+		if l.Removed {
+			continue
+		}
+		ev, err := t.decodeTransfer(l)
+		if err != nil {
+			log.Warn("Skipping malformed Transfer log", "tx", l.TxHash.Hex(), "index", l.Index, "err", err)
+			continue
+		}
+		id := ev.TokenID.String()
+		if ev.To == (common.Address{}) {
+			delete(owners, id)
+			continue
+		}
+		owners[id] = &Ownership{TokenID: ev.TokenID, Owner: ev.To}
+	}
+	if err := it.Err(); err != nil {
+		return nil, err
+	}
+
+	result := make([]*Ownership, 0, len(owners))
+	for _, o := range owners {
+		result = append(result, o)
+	}
+	sort.Sort(ownerships(result))
+	return result, nil
+}
+
+type ownerships []*Ownership
+
+func (o ownerships) Len() int           { return len(o) }
+func (o ownerships) Less(i, j int) bool { return o[i].TokenID.Cmp(o[j].TokenID) < 0 }
+func (o ownerships) Swap(i, j int)      { o[i], o[j] = o[j], o[i] }
+
+func (t *token) query(name string, fromBlock, toBlock *big.Int, first, second []common.Address) ethereum.FilterQuery {
+	return ethClient.EventQuery(t.Address(), parsedABI.Events[name], fromBlock, toBlock, ethClient.AddressTopics(first), ethClient.AddressTopics(second))
+}
+
+func (t *token) decodeTransfer(l types.Log) (*TransferEvent, error) {
+	ev, err := t.decoder.Decode(l)
+	if err != nil {
+		return nil, err
+	}
+	return &TransferEvent{
+		From:    ev.Indexed["from"].(common.Address),
+		To:      ev.Indexed["to"].(common.Address),
+		TokenID: ev.Indexed["tokenId"].(*big.Int),
+		Raw:     l,
+	}, nil
+}
+
+func (t *token) decodeApproval(l types.Log) (*ApprovalEvent, error) {
+	ev, err := t.decoder.Decode(l)
+	if err != nil {
+		return nil, err
+	}
+	return &ApprovalEvent{
+		Owner:    ev.Indexed["owner"].(common.Address),
+		Approved: ev.Indexed["approved"].(common.Address),
+		TokenID:  ev.Indexed["tokenId"].(*big.Int),
+		Raw:      l,
+	}, nil
+}
+
+func (t *token) decodeApprovalForAll(l types.Log) (*ApprovalForAllEvent, error) {
+	ev, err := t.decoder.Decode(l)
+	if err != nil {
+		return nil, err
+	}
+	return &ApprovalForAllEvent{
+		Owner:    ev.Indexed["owner"].(common.Address),
+		Operator: ev.Indexed["operator"].(common.Address),
+		Approved: ev.NonIndexed["approved"].(bool),
+		Raw:      l,
+	}, nil
+}
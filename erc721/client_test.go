@@ -0,0 +1,156 @@
+// Copyright 2017 AMIS Technologies
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package erc721
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	ethrpc "github.com/ethereum/go-ethereum/rpc"
+	ethClient "github.com/getamis/eth-client/client"
+)
+
+// Verify that token implements the Token interface.
+var (
+	_ = Token(&token{})
+)
+
+// FakeCollection is a stand-in for a node serving a token contract deployed
+// at block deployed.
+type FakeCollection struct {
+	head     int64
+	deployed int64
+	logs     []types.Log
+}
+
+func (n *FakeCollection) BlockNumber() *hexutil.Big {
+	return (*hexutil.Big)(big.NewInt(n.head))
+}
+
+func (n *FakeCollection) GetCode(account common.Address, block string) hexutil.Bytes {
+	number, _ := hexutil.DecodeBig(block)
+	if number.Int64() < n.deployed {
+		return nil
+	}
+	return hexutil.Bytes{0x60}
+}
+
+func (n *FakeCollection) GetLogs(args map[string]interface{}) []types.Log {
+	from, _ := hexutil.DecodeUint64(args["fromBlock"].(string))
+	to, _ := hexutil.DecodeUint64(args["toBlock"].(string))
+	logs := []types.Log{}
+	for _, l := range n.logs {
+		if l.BlockNumber >= from && l.BlockNumber <= to {
+			logs = append(logs, l)
+		}
+	}
+	return logs
+}
+
+func newTestClient(t *testing.T, node *FakeCollection) ethClient.Client {
+	srv := ethrpc.NewServer()
+	if err := srv.RegisterName("eth", node); err != nil {
+		t.Fatal(err)
+	}
+	return ethClient.NewClient(ethrpc.DialInProc(srv))
+}
+
+var (
+	testToken = common.HexToAddress("0x00000000000000000000000000000000000000cc")
+	alice     = common.HexToAddress("0x00000000000000000000000000000000000000aa")
+	bob       = common.HexToAddress("0x00000000000000000000000000000000000000bb")
+	carol     = common.HexToAddress("0x00000000000000000000000000000000000000dd")
+)
+
+func transferLog(block uint64, index uint, from, to common.Address, tokenID int64) types.Log {
+	return types.Log{
+		Address:     testToken,
+		Topics:      []common.Hash{parsedABI.Events["Transfer"].Id(), from.Hash(), to.Hash(), common.BigToHash(big.NewInt(tokenID))},
+		BlockNumber: block,
+		Index:       index,
+	}
+}
+
+func TestDeploymentBlock(t *testing.T) {
+	for _, deployed := range []int64{0, 1, 37, 99, 100} {
+		c := newTestClient(t, &FakeCollection{head: 100, deployed: deployed})
+		have, err := NewToken(c, testToken).DeploymentBlock(context.Background())
+		c.Close()
+		if err != nil {
+			t.Errorf("deployed at %d: unexpected error: %v", deployed, err)
+		} else if have.Int64() != deployed {
+			t.Errorf("deployment block mismatch: have %v, want %d", have, deployed)
+		}
+	}
+
+	c := newTestClient(t, &FakeCollection{head: 100, deployed: 101})
+	defer c.Close()
+	if _, err := NewToken(c, testToken).DeploymentBlock(context.Background()); err != ethClient.ErrNoCode {
+		t.Errorf("error mismatch: have %v, want %v", err, ethClient.ErrNoCode)
+	}
+}
+
+func TestOwners(t *testing.T) {
+	removed := transferLog(60, 1, bob, carol, 3)
+	removed.Removed = true
+	malformed := transferLog(65, 0, bob, carol, 3)
+	malformed.Topics = malformed.Topics[:3]
+
+	node := &FakeCollection{head: 100, deployed: 37, logs: []types.Log{
+		transferLog(40, 0, common.Address{}, alice, 1),
+		transferLog(41, 0, common.Address{}, alice, 2),
+		transferLog(50, 0, alice, bob, 1),
+		transferLog(55, 0, alice, common.Address{}, 2),
+		transferLog(60, 0, common.Address{}, bob, 3),
+		removed,
+		malformed,
+		transferLog(70, 0, bob, alice, 3),
+		transferLog(70, 1, bob, carol, 1),
+		transferLog(80, 0, common.Address{}, carol, 2),
+	}}
+	c := newTestClient(t, node)
+	defer c.Close()
+	token := NewToken(c, testToken)
+
+	tests := []struct {
+		from, to *big.Int
+		want     []Ownership
+	}{
+		{want: []Ownership{{big.NewInt(1), carol}, {big.NewInt(2), carol}, {big.NewInt(3), alice}}},
+		{from: big.NewInt(51), to: big.NewInt(69), want: []Ownership{{big.NewInt(3), bob}}},
+		{to: big.NewInt(55), want: []Ownership{{big.NewInt(1), bob}}},
+	}
+	for i, test := range tests {
+		owners, err := token.Owners(context.Background(), test.from, test.to)
+		if err != nil {
+			t.Fatalf("test %d: %v", i, err)
+		}
+		if len(owners) != len(test.want) {
+			t.Errorf("test %d: ownership count mismatch: have %d, want %d", i, len(owners), len(test.want))
+			continue
+		}
+		for j, o := range owners {
+			if o.TokenID.Cmp(test.want[j].TokenID) != 0 || o.Owner != test.want[j].Owner {
+				t.Errorf("test %d: ownership %d mismatch: have %v %x, want %v %x", i, j, o.TokenID, o.Owner, test.want[j].TokenID, test.want[j].Owner)
+			}
+		}
+	}
+}